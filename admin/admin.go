@@ -0,0 +1,140 @@
+package admin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gohttp/response"
+	"github.com/segmentio/events"
+	"github.com/segmentio/tracking-api-chaos/chaos"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// 1mb limit on chaos configs, which is plenty.
+const limit int64 = 1 << 20
+
+// Response
+type Response struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+// Routes.
+var Routes = map[string]string{
+	"/internal/chaos":          "chaos",
+	"/internal/chaos/override": "override",
+}
+
+// Status is what GET returns: the chaos in effect and where it came from.
+type Status struct {
+	Chaos    interface{} `json:"chaos" yaml:"chaos"`
+	Override bool        `json:"override" yaml:"override"`
+	Expires  *time.Time  `json:"expires,omitempty" yaml:"expires,omitempty"`
+}
+
+// Server structure.
+type Server struct {
+	chaos *chaos.Switch
+}
+
+// New returns a new Server that inspects and changes s.
+func New(s *chaos.Switch) *Server {
+	return &Server{chaos: s}
+}
+
+// ServeHTTP handles:
+//
+//	GET    /internal/chaos          the chaos in effect, as YAML or JSON (by Accept)
+//	PUT    /internal/chaos          replace the chaos with the config in the body
+//	POST   /internal/chaos/override push the config in the body for `?ttl=`
+//	DELETE /internal/chaos/override drop the override
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch Routes[r.URL.Path] + " " + r.Method {
+	case "chaos GET":
+		s.get(w, r)
+	case "chaos PUT":
+		s.put(w, r)
+	case "override POST":
+		s.override(w, r)
+	case "override DELETE":
+		s.chaos.SetOverride(nil, 0)
+		events.Log("[admin]: chaos override cleared")
+		response.JSON(w, &Response{Success: true})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) get(w http.ResponseWriter, r *http.Request) {
+	c, expires := s.chaos.Status()
+	status := Status{Chaos: source(c)}
+	if !expires.IsZero() {
+		status.Override = true
+		status.Expires = &expires
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+		return
+	}
+	b, err := yaml.Marshal(status)
+	if err != nil {
+		response.InternalServerError(w, &Response{Message: err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.Write(b)
+}
+
+func (s *Server) put(w http.ResponseWriter, r *http.Request) {
+	config, ok := readConfig(w, r)
+	if !ok {
+		return
+	}
+	s.chaos.Set(config)
+	events.Log("[admin]: chaos replaced")
+	response.JSON(w, &Response{Success: true})
+}
+
+func (s *Server) override(w http.ResponseWriter, r *http.Request) {
+	ttl, err := time.ParseDuration(r.URL.Query().Get("ttl"))
+	if err != nil || ttl <= 0 {
+		response.BadRequest(w, &Response{Message: "ttl must be a positive duration, e.g. ?ttl=30s"})
+		return
+	}
+	config, ok := readConfig(w, r)
+	if !ok {
+		return
+	}
+	s.chaos.SetOverride(config, ttl)
+	events.Log("[admin]: chaos overridden for %{ttl}s", ttl)
+	response.JSON(w, &Response{Success: true})
+}
+
+func readConfig(w http.ResponseWriter, r *http.Request) (*chaos.Config, bool) {
+	b, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		response.BadRequest(w, &Response{Message: err.Error()})
+		return nil, false
+	}
+	// the admin API is unauthenticated, so it mustn't be able to read files
+	config, err := chaos.ParseUntrustedConfig(b)
+	if err != nil {
+		events.Log("[admin]: invalid chaos config: %{error}s", err)
+		response.BadRequest(w, &Response{Message: err.Error()})
+		return nil, false
+	}
+	return config, true
+}
+
+// source returns what c was configured from, if known.
+func source(c chaos.Chaos) interface{} {
+	if config, ok := c.(*chaos.Config); ok {
+		return config.Source
+	}
+	return nil
+}
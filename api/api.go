@@ -1,12 +1,15 @@
 package api
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"strings"
 
 	"github.com/gohttp/app"
 	"github.com/rs/cors"
+	"github.com/segmentio/events"
+	"github.com/segmentio/tracking-api-chaos/admin"
 	"github.com/segmentio/tracking-api-chaos/chaos"
 	"github.com/segmentio/tracking-api-chaos/client"
 	"github.com/segmentio/tracking-api-chaos/crossdomain"
@@ -19,25 +22,64 @@ type Server struct {
 	pixel  http.Handler
 	server http.Handler
 	client http.Handler
-	chaos  chaos.Chaos
+	admin  http.Handler
+	chaos  *chaos.Switch
+	// whether clients may pick their own chaos with the X-Chaos header
+	allowChaosHeader bool
+	// whether the unauthenticated admin API is served
+	allowAdmin bool
 	*app.App
 }
 
-func New(out io.Writer, chaosRoot chaos.Chaos) *Server {
+func New(out io.Writer, dropped io.Writer, chaosRoot chaos.Chaos) *Server {
 	api := &Server{
 		App:   app.New(),
-		chaos: chaosRoot,
+		chaos: chaos.NewSwitch(chaosRoot),
 	}
-	tracker := tracker.New(out)
+	tracker := tracker.New(out, dropped)
 	api.pixel = pixel.New(tracker)
 	api.client = cors.Default().Handler(client.New(tracker))
 	api.server = server.New(tracker)
+	api.admin = admin.New(api.chaos)
 	api.Use(api.route)
 	api.Get("/internal/health", api.health)
 	api.Get("/crossdomain.xml", crossdomain.Route)
 	return api
 }
 
+// Chaos returns the switch holding the chaos applied to requests.
+func (s *Server) Chaos() *chaos.Switch {
+	return s.chaos
+}
+
+// AllowChaosHeader sets whether clients may pick their own chaos with the
+// X-Chaos header, instead of the configured chaos.
+func (s *Server) AllowChaosHeader(allow bool) {
+	s.allowChaosHeader = allow
+}
+
+// AllowAdmin sets whether the admin API (see admin.Routes) is served. It has no
+// authentication, so anyone who can reach the server can change its chaos.
+func (s *Server) AllowAdmin(allow bool) {
+	s.allowAdmin = allow
+}
+
+// chaosFor returns the chaos to apply to r. The X-Chaos header is removed
+// either way, so it never ends up in the recorded message.
+func (s *Server) chaosFor(r *http.Request) chaos.Chaos {
+	value := r.Header.Get(chaos.Header)
+	r.Header.Del(chaos.Header)
+	if value == "" || !s.allowChaosHeader {
+		return s.chaos
+	}
+	c, err := chaos.ParseHeader(value)
+	if err != nil {
+		events.Log("[api]: ignoring %{header}s header %{value}q: %{error}s", chaos.Header, value, err)
+		return s.chaos
+	}
+	return c
+}
+
 // Route routes using `pkg.Routes` to each server.
 func (s *Server) route(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -49,6 +91,12 @@ func (s *Server) route(h http.Handler) http.Handler {
 			path = r.URL.Path
 		}
 
+		// Chaos isn't applied to the admin API, so it can always be reached.
+		if _, ok := admin.Routes[path]; ok && s.allowAdmin {
+			s.admin.ServeHTTP(w, r)
+			return
+		}
+
 		var downstream http.Handler
 
 		if _, ok := pixel.Routes[path]; ok {
@@ -61,7 +109,18 @@ func (s *Server) route(h http.Handler) http.Handler {
 			downstream = h
 		}
 
-		w, r = s.chaos.Do(w, r)
+		// Cancel the context once the request is handled, so chaos can tell
+		// when it's done even if the server wouldn't cancel it (e.g. in tests).
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		w, r = s.chaosFor(r).Do(w, r)
+		if r == nil {
+			return
+		}
+		// Like the X-Chaos header, the redirect hop count isn't part of the message.
+		chaos.StripHops(r)
 
 		downstream.ServeHTTP(w, r)
 	})
@@ -0,0 +1,78 @@
+package chaos
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Let the downstream handler run, publishing the message, then throw its
+// response away and send `Code` (500 if unset) and `Body` instead. If `Close`
+// is set the connection is closed without any response at all.
+type AckFailureChaos struct {
+	Code  int    `mapstructure:"code"`
+	Body  []byte `mapstructure:"body"`
+	Close bool   `mapstructure:"close"`
+}
+
+func decodeAckFailure(v interface{}) (c AckFailureChaos, err error) {
+	if err = decode(v, &c); err != nil {
+		return c, fmt.Errorf("invalid ackFailure: %s", err)
+	}
+	if c.Code != 0 {
+		if err = checkCode(AckFailure, c.Code); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+func (c AckFailureChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	return &ackFailureWriter{ResponseWriter: w, chaos: c}, r
+}
+
+// ackFailureWriter fails the request as soon as the downstream handler starts
+// writing its response; every handler publishes before it does so.
+type ackFailureWriter struct {
+	http.ResponseWriter
+	chaos  AckFailureChaos
+	header http.Header
+	failed bool
+}
+
+// Header returns a header map that is never sent, so the handler's headers
+// don't leak into the failure.
+func (a *ackFailureWriter) Header() http.Header {
+	if a.header == nil {
+		a.header = make(http.Header)
+	}
+	return a.header
+}
+
+func (a *ackFailureWriter) WriteHeader(code int) {
+	a.fail()
+}
+
+func (a *ackFailureWriter) Write(b []byte) (int, error) {
+	a.fail()
+	return len(b), nil
+}
+
+func (a *ackFailureWriter) fail() {
+	if a.failed {
+		return
+	}
+	a.failed = true
+
+	if a.chaos.Close {
+		hijack(a.ResponseWriter).Close()
+		return
+	}
+	code := a.chaos.Code
+	if code == 0 {
+		code = http.StatusInternalServerError
+	}
+	a.ResponseWriter.WriteHeader(code)
+	if a.chaos.Body != nil {
+		a.ResponseWriter.Write(a.chaos.Body)
+	}
+}
@@ -0,0 +1,34 @@
+package chaos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAckFailure(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/v1/track", nil)
+	published := false
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		published = true
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"success":true}`))
+	}
+
+	handler(AckFailureChaos{Code: 503, Body: []byte("unavailable")}.Do(rec, req))
+
+	if !published {
+		t.Error("expected downstream handler to run")
+	}
+	if rec.Code != 503 {
+		t.Errorf("expected 503; got %d", rec.Code)
+	}
+	if rec.Body.String() != "unavailable" {
+		t.Errorf("expected failure body; got %q", rec.Body.String())
+	}
+	if rec.Header().Get("Content-Type") != "" {
+		t.Errorf("expected handler headers to be discarded; got %v", rec.Header())
+	}
+}
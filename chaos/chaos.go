@@ -2,9 +2,7 @@ package chaos
 
 import (
 	"fmt"
-	"math/rand"
 	"net/http"
-	"time"
 
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/mitchellh/mapstructure"
@@ -46,59 +44,94 @@ func (f *FakeResponseWriter) WriteHeader(statusCode int) {
 type Kind string
 
 const (
-	StatusCode Kind = "statusCode"
-	Latency    Kind = "latency"
+	StatusCode  Kind = "statusCode"
+	Latency     Kind = "latency"
+	Reset       Kind = "reset"
+	Truncate    Kind = "truncate"
+	Throttle    Kind = "throttle"
+	SlowBody    Kind = "slowBody"
+	CutBody     Kind = "cutBody"
+	Drop        Kind = "drop"
+	Duplicate   Kind = "duplicate"
+	AckFailure  Kind = "ackFailure"
+	Malformed   Kind = "malformed"
+	Redirect    Kind = "redirect"
+	SizeLimit   Kind = "sizeLimit"
+	Profiles    Kind = "profiles"
+	Schedule    Kind = "schedule"
+	Flap        Kind = "flap"
+	RateLimit   Kind = "rateLimit"
+	Concurrency Kind = "concurrency"
+	Sequence    Kind = "sequence"
+	Weighted    Kind = "weighted"
+	Hashed      Kind = "hashed"
 )
 
+// A Chaos may return a nil *http.Request to stop the request from reaching
+// the downstream handler, e.g. because the connection is gone. The request's
+// context is done once the request has been handled.
 type Chaos interface {
 	Do(http.ResponseWriter, *http.Request) (http.ResponseWriter, *http.Request)
-	// TODO: interrupt?
 }
 
-// Write a specific HTTP status code and body
-type StatusCodeChaos struct {
-	Code int    `mapstructure:"code"`
-	Body []byte `mapstructure:"body"`
-}
-
-// If Body is not nil, w will be replaced with a FakeResponseWriter
-func (c StatusCodeChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
-	w.WriteHeader(c.Code)
-	if c.Body == nil {
-		return w, r
-	}
-	w.Write(c.Body)
-
-	return &FakeResponseWriter{}, r
-}
+const DefaultWeight float64 = 100
 
-// Delay request by some amount. Amount is `Latency` ms plus or minus a random amount
-// of jitter, up to `Jitter` ms
-type LatencyChaos struct {
-	Latency int64 `mapstructure:"latency"`
-	Jitter  int64 `mapstructure:"jitter"`
-}
+// The decoder of each kind of chaos, from its config. It's filled in by init,
+// as decoding weighted chaos refers back to it.
+var decoders map[Kind]func(interface{}) (Chaos, error)
 
-func (c LatencyChaos) delay() {
-	delay := c.Latency
-	jitter := c.Jitter
-	if jitter > 0 {
-		delay = rand.Int63n(jitter*2) - jitter
+func init() {
+	decoders = map[Kind]func(interface{}) (Chaos, error){
+		StatusCode:  func(v interface{}) (Chaos, error) { return decodeStatusCode(v) },
+		Latency:     func(v interface{}) (Chaos, error) { return decodeLatency(v) },
+		Reset:       func(v interface{}) (Chaos, error) { return decodeReset(v) },
+		Truncate:    func(v interface{}) (Chaos, error) { return decodeTruncate(v) },
+		Throttle:    func(v interface{}) (Chaos, error) { return decodeThrottle(v) },
+		SlowBody:    func(v interface{}) (Chaos, error) { return decodeSlowBody(v) },
+		CutBody:     func(v interface{}) (Chaos, error) { return decodeCutBody(v) },
+		Drop:        func(v interface{}) (Chaos, error) { return decodeDrop(v) },
+		Duplicate:   func(v interface{}) (Chaos, error) { return decodeDuplicate(v) },
+		AckFailure:  func(v interface{}) (Chaos, error) { return decodeAckFailure(v) },
+		Malformed:   func(v interface{}) (Chaos, error) { return decodeMalformed(v) },
+		Redirect:    func(v interface{}) (Chaos, error) { return decodeRedirect(v) },
+		SizeLimit:   func(v interface{}) (Chaos, error) { return decodeSizeLimit(v) },
+		Profiles:    func(v interface{}) (Chaos, error) { return decodeProfiles(v) },
+		Schedule:    func(v interface{}) (Chaos, error) { return decodeSchedule(v) },
+		Flap:        func(v interface{}) (Chaos, error) { return decodeFlap(v) },
+		RateLimit:   func(v interface{}) (Chaos, error) { return decodeRateLimit(v) },
+		Concurrency: func(v interface{}) (Chaos, error) { return decodeConcurrency(v) },
+		Sequence:    func(v interface{}) (Chaos, error) { return decodeSequence(v) },
+		Hashed:      func(v interface{}) (Chaos, error) { return decodeHashed(v) },
+		Weighted: func(v interface{}) (Chaos, error) {
+			c, err := decodeWeightedChaos(v)
+			if err != nil {
+				return nil, fmt.Errorf("weighted: %s", err)
+			}
+			return c, nil
+		},
 	}
-	// TODO: this is blocking; do we need a way to interrupt?
-	time.Sleep(time.Duration(delay) * time.Millisecond)
 }
 
-func (c LatencyChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
-	c.delay()
-	return w, r
+// decode is mapstructure.Decode, but lenient about types, so that e.g. a string
+// body can be decoded into a []byte, and strict about fields, so that a typo
+// isn't silently ignored.
+func decode(input interface{}, output interface{}) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		ErrorUnused:      true,
+		WeaklyTypedInput: true,
+		Result:           output,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(input)
 }
 
-const DefaultWeight float64 = 100
-
 type WeightedChaosItem struct {
 	Weight float64
-	Chaos  Chaos
+	// Match, if not nil, limits the item to the requests it matches
+	Match *Match
+	Chaos Chaos
 }
 
 type WeightedChaos []WeightedChaosItem
@@ -110,6 +143,41 @@ func (c *WeightedChaos) UnmarshalYAML(unmarshal func(interface{}) error) (err er
 		events.Log("failed basic unmarshal: %{error}s", err)
 		return err
 	}
+	*c, err = decodeItems(itemsmap)
+	return err
+}
+
+// decodeWeightedChaos decodes a list of chaos items nested inside another
+// chaos, where YAML maps come through as map[interface{}]interface{}.
+func decodeWeightedChaos(v interface{}) (WeightedChaos, error) {
+	itemsmap, err := decodeItemsMap(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeItems(itemsmap)
+}
+
+func decodeItemsMap(v interface{}) ([]map[string]interface{}, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a list of chaos items; got %#v", v)
+	}
+	itemsmap := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		m, ok := item.(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected a chaos item; got %#v", item)
+		}
+		itemmap := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			itemmap[fmt.Sprint(k)] = v
+		}
+		itemsmap = append(itemsmap, itemmap)
+	}
+	return itemsmap, nil
+}
+
+func decodeItems(itemsmap []map[string]interface{}) (c WeightedChaos, err error) {
 	var weightsum float64 = 0
 	for _, item := range itemsmap {
 		var weight float64
@@ -128,6 +196,14 @@ func (c *WeightedChaos) UnmarshalYAML(unmarshal func(interface{}) error) (err er
 			}
 		}
 		delete(item, "weight")
+		var match *Match
+		if match_untyped, ok := item["match"]; ok {
+			match, err = decodeMatch(match_untyped, err)
+			delete(item, "match")
+			if match == nil {
+				continue
+			}
+		}
 		if len(item) != 1 {
 			err = multierror.Append(err, fmt.Errorf("item must have exactly 1 chaos; has %d: %s", len(item), item))
 			continue
@@ -138,24 +214,24 @@ func (c *WeightedChaos) UnmarshalYAML(unmarshal func(interface{}) error) (err er
 		// TODO? better way to do this
 		for k, v = range item {
 		}
-		var chaos Chaos
-		kind := Kind(k)
-		switch kind {
-		case StatusCode:
-			chaosTyped := StatusCodeChaos{}
-			mapstructure.Decode(v, &chaosTyped)
-			chaos = chaosTyped
-		case Latency:
-			chaosTyped := LatencyChaos{}
-			mapstructure.Decode(v, &chaosTyped)
-			chaos = chaosTyped
-		default:
+		decoder, ok := decoders[Kind(k)]
+		if !ok {
 			err = multierror.Append(err, fmt.Errorf("unrecognized chaos type `%s`", k))
 			continue
 		}
-		weightsum += weight
-		*c = append(*c, WeightedChaosItem{
+		chaos, decodeErr := decoder(v)
+		if decodeErr != nil {
+			err = multierror.Append(err, decodeErr)
+			continue
+		}
+		// matched items may be for disjoint requests, so they're summed below,
+		// with the items matching the same requests
+		if match == nil {
+			weightsum += weight
+		}
+		c = append(c, WeightedChaosItem{
 			Weight: weight,
+			Match:  match,
 			Chaos:  chaos,
 		},
 		)
@@ -163,10 +239,33 @@ func (c *WeightedChaos) UnmarshalYAML(unmarshal func(interface{}) error) (err er
 	if weightsum > 100 {
 		err = multierror.Append(err, fmt.Errorf("sum of weights must be < 100; is %f", weightsum))
 	}
+	for i, item := range c {
+		if item.Match == nil || sameMatchBefore(c[:i], item.Match) {
+			continue
+		}
+		sum := weightsum
+		for _, other := range c[i:] {
+			if other.Match != nil && other.Match.Same(item.Match) {
+				sum += other.Weight
+			}
+		}
+		if sum > 100 {
+			err = multierror.Append(err, fmt.Errorf("sum of weights of unmatched items and items with the same match as item %d must be < 100; is %f", i+1, sum))
+		}
+	}
 	if err != nil {
-		*c = nil
+		c = nil
 	}
-	return err
+	return c, err
+}
+
+func sameMatchBefore(items WeightedChaos, m *Match) bool {
+	for _, item := range items {
+		if item.Match != nil && item.Match.Same(m) {
+			return true
+		}
+	}
+	return false
 }
 
 func (c WeightedChaos) Choose(i float64) Chaos {
@@ -179,10 +278,21 @@ func (c WeightedChaos) Choose(i float64) Chaos {
 	return nil
 }
 
+// Matching returns the items whose Match matches r. Weights are left as is, so
+// an item is chosen with the same probability whatever else is configured.
+func (c WeightedChaos) Matching(r *http.Request) WeightedChaos {
+	matching := make(WeightedChaos, 0, len(c))
+	for _, item := range c {
+		if item.Match == nil || item.Match.Matches(r) {
+			matching = append(matching, item)
+		}
+	}
+	return matching
+}
+
 func (c WeightedChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
-	rander := rand.New(rand.NewSource(time.Now().UnixNano()))
-	i := rander.Float64() * 100
-	chaos := c.Choose(i)
+	i := Rand.Float64() * 100
+	chaos := c.Matching(r).Choose(i)
 	if chaos != nil {
 		events.Debug("Causing chaos %#v", chaos)
 		w, r = chaos.Do(w, r)
@@ -1,8 +1,11 @@
 package chaos
 
 import (
+	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 )
@@ -70,3 +73,60 @@ func TestDecode(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestDecodeStringBody(t *testing.T) {
+	config, err := ParseConfig([]byte(DefaultConfigYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chaos, ok := config.Chaos[2].Chaos.(StatusCodeChaos)
+	if !ok || string(chaos.Body) != "Something went wrong" {
+		t.Errorf("expected statusCode body to be decoded; got %#v", config.Chaos[2].Chaos)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	for _, config := range []string{
+		"- reset:\n    readBody: abc",
+		"- truncate:\n    bytes: abc",
+		"- truncate:\n    bytes: -1",
+		"- truncate:\n    fraction: 1.5",
+		"- throttle:\n    bytesPerSecond: abc",
+		"- throttle:\n    bytesPerSecond: 0",
+		"- throttle:\n    bytesPerSecond: 10\n    chunkSize: -1",
+		"- slowBody:\n    bytesPerSecond: abc",
+		"- slowBody:\n    bytesPerSecond: -5",
+		"- drop: abc",
+		"- duplicate:\n    copies: abc",
+		"- duplicate:\n    copies: 1",
+		"- duplicate:\n    delay: -100",
+		"- ackFailure:\n    code: abc",
+		"- ackFailure:\n    code: 7",
+		"- cutBody:\n    bytes: abc",
+		"- cutBody:\n    fraction: -0.5",
+		"- statusCode:\n    code: 503\n    header:\n      Retry-After: 30",
+		"- latency:\n    latncy: 100",
+	} {
+		if _, err := ParseConfig([]byte(config)); err == nil {
+			t.Errorf("%q: expected error", config)
+		}
+	}
+}
+
+func TestLatencyClientDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("POST", "/v1/track", nil).WithContext(ctx)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, req = LatencyChaos{Latency: 10000}.Do(httptest.NewRecorder(), req)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected latency to be cut short; took %s", elapsed)
+	}
+	if req != nil {
+		t.Error("expected request to be stopped once the client is gone")
+	}
+}
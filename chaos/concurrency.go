@@ -0,0 +1,78 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/segmentio/events"
+)
+
+// Let at most `Max` requests through at once. Requests over the limit wait up
+// to `QueueTimeout` ms for a slot, then get `Code` (503 if unset) and `Body`
+// without reaching the handler.
+type ConcurrencyChaos struct {
+	Max          int    `mapstructure:"max"`
+	QueueTimeout int64  `mapstructure:"queueTimeout"`
+	Code         int    `mapstructure:"code"`
+	Body         []byte `mapstructure:"body"`
+
+	slots chan struct{}
+}
+
+func decodeConcurrency(v interface{}) (*ConcurrencyChaos, error) {
+	c := &ConcurrencyChaos{}
+	if err := decode(v, c); err != nil {
+		return nil, fmt.Errorf("invalid concurrency: %s", err)
+	}
+	if c.Max <= 0 {
+		return nil, fmt.Errorf("concurrency: max must be > 0")
+	}
+	if c.Code == 0 {
+		c.Code = http.StatusServiceUnavailable
+	}
+	if err := checkCode(Concurrency, c.Code); err != nil {
+		return nil, err
+	}
+	c.slots = make(chan struct{}, c.Max)
+	return c, nil
+}
+
+func (c *ConcurrencyChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	if c.acquire(r.Context()) {
+		return w, r
+	}
+	events.Debug("[chaos]: over %{max}d concurrent requests", c.Max)
+	w.WriteHeader(c.Code)
+	if c.Body != nil {
+		w.Write(c.Body)
+	}
+	return w, nil
+}
+
+// acquire takes a slot, waiting up to QueueTimeout for one, and gives it back
+// once ctx is done.
+func (c *ConcurrencyChaos) acquire(ctx context.Context) bool {
+	select {
+	case c.slots <- struct{}{}:
+	default:
+		if c.QueueTimeout <= 0 {
+			return false
+		}
+		timer := time.NewTimer(time.Duration(c.QueueTimeout) * time.Millisecond)
+		defer timer.Stop()
+		select {
+		case c.slots <- struct{}{}:
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+			return false
+		}
+	}
+	go func() {
+		<-ctx.Done()
+		<-c.slots
+	}()
+	return true
+}
@@ -0,0 +1,44 @@
+package chaos
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConcurrency(t *testing.T) {
+	c, err := decodeConcurrency(map[interface{}]interface{}{
+		"max":          1,
+		"queueTimeout": 50,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(ctx context.Context) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/v1/batch", nil).WithContext(ctx)
+		c.Do(rec, req)
+		return rec.Code
+	}
+
+	first, done := context.WithCancel(context.Background())
+	if code := request(first); code != 200 {
+		t.Errorf("expected first request through; got %d", code)
+	}
+	if code := request(context.Background()); code != 503 {
+		t.Errorf("expected second request to be rejected; got %d", code)
+	}
+
+	// the queued request gets the slot once the first is done
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		done()
+	}()
+	second, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if code := request(second); code != 200 {
+		t.Errorf("expected queued request through; got %d", code)
+	}
+}
@@ -0,0 +1,96 @@
+package chaos
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Config is a WeightedChaos along with the document it was decoded from, so
+// it can be shown back the way it was written.
+type Config struct {
+	Chaos  WeightedChaos
+	Source interface{}
+}
+
+// ParseConfig parses a YAML (or JSON) chaos config.
+func ParseConfig(b []byte) (*Config, error) {
+	c := &Config{}
+	if err := yaml.Unmarshal(b, &c.Chaos); err != nil {
+		return nil, err
+	}
+	var source interface{}
+	if err := yaml.Unmarshal(b, &source); err != nil {
+		return nil, err
+	}
+	c.Source = normalize(source)
+	return c, nil
+}
+
+// ParseUntrustedConfig is ParseConfig for configs from clients rather than the
+// operator, which may not use fields that read files on the server.
+func ParseUntrustedConfig(b []byte) (*Config, error) {
+	var source interface{}
+	if err := yaml.Unmarshal(b, &source); err != nil {
+		return nil, err
+	}
+	if field := fileField(source); field != "" {
+		return nil, fmt.Errorf("`%s` is only allowed in the chaos config file", field)
+	}
+	return ParseConfig(b)
+}
+
+// The fields that read files on the server, lower cased since fields are
+// decoded case insensitively.
+var fileFields = map[string]bool{
+	"bodyfile": true,
+}
+
+// fileField returns the first field in v, a decoded YAML document, that reads
+// a file on the server, or "" if there is none.
+func fileField(v interface{}) string {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		for k, item := range v {
+			if fileFields[strings.ToLower(fmt.Sprint(k))] {
+				return fmt.Sprint(k)
+			}
+			if field := fileField(item); field != "" {
+				return field
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if field := fileField(item); field != "" {
+				return field
+			}
+		}
+	}
+	return ""
+}
+
+func (c *Config) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	return c.Chaos.Do(w, r)
+}
+
+// normalize turns the map[interface{}]interface{}s that YAML decodes to into
+// map[string]interface{}s, which can be encoded as JSON.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = normalize(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalize(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
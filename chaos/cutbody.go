@@ -0,0 +1,110 @@
+package chaos
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Let the downstream handler start reading the request body, then close the
+// connection once it has read `Bytes` of it, or `Fraction` of its
+// Content-Length if that's set and known. At least one byte is always left
+// unread. If `Reset` is set the connection is reset rather than closed.
+type CutBodyChaos struct {
+	Bytes    int64   `mapstructure:"bytes"`
+	Fraction float64 `mapstructure:"fraction"`
+	Reset    bool    `mapstructure:"reset"`
+}
+
+func decodeCutBody(v interface{}) (c CutBodyChaos, err error) {
+	if err = decode(v, &c); err != nil {
+		return c, fmt.Errorf("invalid cutBody: %s", err)
+	}
+	if c.Bytes < 0 {
+		return c, fmt.Errorf("cutBody: bytes must be >= 0; is %d", c.Bytes)
+	}
+	if c.Fraction < 0 || c.Fraction > 1 {
+		return c, fmt.Errorf("cutBody: fraction must be between 0 and 1; is %f", c.Fraction)
+	}
+	return c, nil
+}
+
+func (c CutBodyChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	if r.Body == nil {
+		return w, r
+	}
+	body := &cutReader{ReadCloser: r.Body, w: w, chaos: c, remaining: c.keep(r.ContentLength)}
+	r.Body = body
+	return &cutWriter{ResponseWriter: w, body: body}, r
+}
+
+// keep returns how much of an n byte body to read, n being -1 if unknown.
+func (c CutBodyChaos) keep(n int64) int64 {
+	keep := c.Bytes
+	if c.Fraction > 0 && n >= 0 {
+		keep = int64(float64(n) * c.Fraction)
+	}
+	if n >= 0 && keep >= n {
+		keep = n - 1
+	}
+	if keep < 0 {
+		keep = 0
+	}
+	return keep
+}
+
+// cutReader closes the connection once `remaining` bytes have been read, or
+// the body ends, whichever comes first. Reads fail from then on.
+type cutReader struct {
+	io.ReadCloser
+	w         http.ResponseWriter
+	chaos     CutBodyChaos
+	remaining int64
+	cut       bool
+}
+
+func (c *cutReader) Read(p []byte) (int, error) {
+	if c.cut {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	var n int
+	var err error
+	if len(p) > 0 {
+		n, err = c.ReadCloser.Read(p)
+		c.remaining -= int64(n)
+	}
+	if c.remaining > 0 && err == nil {
+		return n, nil
+	}
+
+	c.cut = true
+	conn := hijack(c.w)
+	if c.chaos.Reset {
+		reset(conn)
+	} else {
+		conn.Close()
+	}
+	return n, io.ErrUnexpectedEOF
+}
+
+// cutWriter drops the handler's response once the connection is gone.
+type cutWriter struct {
+	http.ResponseWriter
+	body *cutReader
+}
+
+func (c *cutWriter) WriteHeader(code int) {
+	if !c.body.cut {
+		c.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (c *cutWriter) Write(b []byte) (int, error) {
+	if c.body.cut {
+		return len(b), nil
+	}
+	return c.ResponseWriter.Write(b)
+}
@@ -0,0 +1,67 @@
+package chaos
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCutBody(t *testing.T) {
+	var gzipped bytes.Buffer
+	z := gzip.NewWriter(&gzipped)
+	z.Write([]byte(`{"batch":[` + strings.Repeat(`{"userId":"user-id"},`, 1000) + `{}]}`))
+	z.Close()
+
+	for _, c := range []CutBodyChaos{{Bytes: 100}, {Fraction: 0.5}, {Bytes: 100, Reset: true}} {
+		var read int64
+		var readErr error
+		handled := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer close(handled)
+			w, r = c.Do(w, r)
+			z, err := gzip.NewReader(r.Body)
+			if err != nil {
+				readErr = err
+				return
+			}
+			var b []byte
+			b, readErr = ioutil.ReadAll(z)
+			read = int64(len(b))
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		_, err := http.Post(srv.URL, "application/json", bytes.NewReader(gzipped.Bytes()))
+		if err == nil {
+			t.Errorf("%+v: expected connection error", c)
+		}
+		<-handled
+		if readErr == nil {
+			t.Errorf("%+v: expected the handler to fail reading the body; read %d bytes", c, read)
+		}
+		srv.Close()
+	}
+}
+
+func TestCutBodyKeep(t *testing.T) {
+	tests := []struct {
+		chaos  CutBodyChaos
+		length int64
+		keep   int64
+	}{
+		{CutBodyChaos{Bytes: 10}, 100, 10},
+		{CutBodyChaos{Bytes: 10}, -1, 10},
+		{CutBodyChaos{Bytes: 1000}, 100, 99},
+		{CutBodyChaos{Fraction: 0.25}, 100, 25},
+		{CutBodyChaos{Bytes: 10, Fraction: 0.25}, -1, 10},
+		{CutBodyChaos{Fraction: 1}, 100, 99},
+	}
+	for _, test := range tests {
+		if keep := test.chaos.keep(test.length); keep != test.keep {
+			t.Errorf("%+v of %d bytes: expected to keep %d; got %d", test.chaos, test.length, test.keep, keep)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package chaos
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/segmentio/tracking-api-chaos/tracker"
+)
+
+// Respond as usual, but write the message to the tracker's dropped stream
+// instead of out, as if it were lost after being acknowledged.
+type DropChaos struct{}
+
+func decodeDrop(v interface{}) (c DropChaos, err error) {
+	if err = decode(v, &c); err != nil {
+		return c, fmt.Errorf("invalid drop: %s", err)
+	}
+	return c, nil
+}
+
+func (c DropChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	return w, r.WithContext(tracker.WithDrop(r.Context()))
+}
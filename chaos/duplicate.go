@@ -0,0 +1,40 @@
+package chaos
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/segmentio/tracking-api-chaos/tracker"
+)
+
+// Write the message `Copies` times (2 if unset), `Delay` ms apart, so that
+// consumers of out have to deduplicate on messageId. A single copy isn't a
+// duplicate, so `copies: 1` is rejected rather than taken as unset. Copies still waiting
+// when Shutdown is called are dropped.
+type DuplicateChaos struct {
+	Copies int   `mapstructure:"copies"`
+	Delay  int64 `mapstructure:"delay"`
+}
+
+func decodeDuplicate(v interface{}) (c DuplicateChaos, err error) {
+	if err = decode(v, &c); err != nil {
+		return c, fmt.Errorf("invalid duplicate: %s", err)
+	}
+	if c.Copies < 0 || c.Copies == 1 {
+		return c, fmt.Errorf("duplicate: copies must be unset or >= 2; is %d", c.Copies)
+	}
+	if c.Delay < 0 {
+		return c, fmt.Errorf("duplicate: delay must be >= 0; is %d", c.Delay)
+	}
+	return c, nil
+}
+
+func (c DuplicateChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	copies := c.Copies
+	if copies == 0 {
+		copies = 2
+	}
+	delay := time.Duration(c.Delay) * time.Millisecond
+	return w, r.WithContext(tracker.WithDuplicates(r.Context(), copies, delay, shutdown))
+}
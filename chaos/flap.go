@@ -0,0 +1,109 @@
+package chaos
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/segmentio/events"
+)
+
+// Flip between an "up" and a "degraded" state, each with its own chaos. The
+// time spent in a state is exponentially distributed around its `MeanDwell`
+// (ms), so failures come in correlated bursts rather than independent rolls.
+type FlapChaos struct {
+	Up       FlapState
+	Degraded FlapState
+
+	mu       sync.Mutex
+	degraded bool
+	until    time.Time
+}
+
+type FlapState struct {
+	MeanDwell time.Duration
+	Chaos     WeightedChaos
+}
+
+func decodeFlap(v interface{}) (*FlapChaos, error) {
+	var raw struct {
+		Up       map[string]interface{} `mapstructure:"up"`
+		Degraded map[string]interface{} `mapstructure:"degraded"`
+	}
+	if err := decode(v, &raw); err != nil {
+		return nil, fmt.Errorf("invalid flap: %s", err)
+	}
+	c := &FlapChaos{}
+	var err error
+	if c.Up, err = decodeFlapState(raw.Up); err != nil {
+		return nil, fmt.Errorf("flap state `up`: %s", err)
+	}
+	if c.Degraded, err = decodeFlapState(raw.Degraded); err != nil {
+		return nil, fmt.Errorf("flap state `degraded`: %s", err)
+	}
+	c.until = now().Add(c.Up.dwell())
+	return c, nil
+}
+
+func decodeFlapState(v map[string]interface{}) (s FlapState, err error) {
+	var raw struct {
+		MeanDwell int64       `mapstructure:"meanDwell"`
+		Chaos     interface{} `mapstructure:"chaos"`
+	}
+	if err = decode(v, &raw); err != nil {
+		return
+	}
+	if raw.MeanDwell <= 0 {
+		return s, fmt.Errorf("meanDwell must be > 0")
+	}
+	s.MeanDwell = time.Duration(raw.MeanDwell) * time.Millisecond
+	if raw.Chaos != nil {
+		s.Chaos, err = decodeWeightedChaos(raw.Chaos)
+	}
+	return
+}
+
+func (s FlapState) dwell() time.Duration {
+	return time.Duration(Rand.ExpFloat64() * float64(s.MeanDwell))
+}
+
+// forgetCycles is how many up and degraded cycles State replays at most.
+const forgetCycles = 10
+
+// State returns whether the chaos is degraded at t, moving through however
+// many states have come and gone since it was last asked.
+func (c *FlapChaos) State(t time.Time) (degraded bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// After a long idle spell, replaying every flip could take a while; the
+	// state by then no longer depends on where it was, so draw it afresh.
+	// Dwell times are exponential, so the time left in it is just a dwell.
+	cycle := c.Up.MeanDwell + c.Degraded.MeanDwell
+	if t.Sub(c.until) > forgetCycles*cycle {
+		c.degraded = Rand.Float64()*float64(cycle) < float64(c.Degraded.MeanDwell)
+		if c.degraded {
+			c.until = t.Add(c.Degraded.dwell())
+		} else {
+			c.until = t.Add(c.Up.dwell())
+		}
+		events.Debug("[chaos]: reseeded flap to degraded=%{degraded}t until %{until}s", c.degraded, c.until)
+	}
+	for !t.Before(c.until) {
+		c.degraded = !c.degraded
+		if c.degraded {
+			c.until = c.until.Add(c.Degraded.dwell())
+		} else {
+			c.until = c.until.Add(c.Up.dwell())
+		}
+		events.Debug("[chaos]: flapped to degraded=%{degraded}t until %{until}s", c.degraded, c.until)
+	}
+	return c.degraded
+}
+
+func (c *FlapChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	if c.State(now()) {
+		return c.Degraded.Chaos.Do(w, r)
+	}
+	return c.Up.Chaos.Do(w, r)
+}
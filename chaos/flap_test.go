@@ -0,0 +1,74 @@
+package chaos
+
+import (
+	"testing"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestFlap(t *testing.T) {
+	var wc WeightedChaos
+	err := yaml.Unmarshal([]byte(`
+- flap:
+    up:
+      meanDwell: 1000
+    degraded:
+      meanDwell: 1000
+      chaos:
+        - statusCode:
+            code: 503
+`), &wc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flap := wc[0].Chaos.(*FlapChaos)
+
+	// sample every 100ms for a long while; with equal dwells the state
+	// should be degraded about half the time, and change in runs
+	start := time.Now()
+	degraded, changes := 0, 0
+	last := false
+	for i := 0; i < 10000; i++ {
+		state := flap.State(start.Add(time.Duration(i) * 100 * time.Millisecond))
+		if state {
+			degraded++
+		}
+		if state != last {
+			changes++
+		}
+		last = state
+	}
+	if degraded < 4000 || degraded > 6000 {
+		t.Errorf("expected to be degraded about half the time; was %d/10000", degraded)
+	}
+	if changes < 500 || changes > 1500 {
+		t.Errorf("expected about 1000 state changes; got %d", changes)
+	}
+}
+
+func TestFlapIdle(t *testing.T) {
+	flap, err := decodeFlap(map[interface{}]interface{}{
+		"up":       map[interface{}]interface{}{"meanDwell": 1},
+		"degraded": map[interface{}]interface{}{"meanDwell": 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	at := start
+	degraded := 0
+	for i := 0; i < 1000; i++ {
+		at = at.Add(6 * time.Hour)
+		if flap.State(at) {
+			degraded++
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected long idle spells not to be replayed; took %s", elapsed)
+	}
+	if degraded < 400 || degraded > 600 {
+		t.Errorf("expected to be degraded about half the time; was %d/1000", degraded)
+	}
+}
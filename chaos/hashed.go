@@ -0,0 +1,60 @@
+package chaos
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+
+	"github.com/segmentio/events"
+	"github.com/segmentio/tracking-api-chaos/message"
+)
+
+// Choose from `Chaos` by a hash of the message field `By` (e.g. messageId, see
+// message.Field) rather than at random, so the same event always meets the
+// same fate. Requests without the field are rolled at random. As with
+// ProfilesChaos, body chaos only acts on the body after the field.
+type HashedChaos struct {
+	By    string
+	Chaos WeightedChaos
+}
+
+func decodeHashed(v interface{}) (c HashedChaos, err error) {
+	var raw struct {
+		By    string      `mapstructure:"by"`
+		Chaos interface{} `mapstructure:"chaos"`
+	}
+	if err = decode(v, &raw); err != nil {
+		return c, fmt.Errorf("invalid hashed: %s", err)
+	}
+	if raw.By == "" {
+		return c, fmt.Errorf("hashed: by must be set")
+	}
+	c.By = raw.By
+	if raw.Chaos != nil {
+		if c.Chaos, err = decodeWeightedChaos(raw.Chaos); err != nil {
+			return c, fmt.Errorf("hashed: %s", err)
+		}
+	}
+	return c, nil
+}
+
+// Roll returns where in [0, 100) value lands.
+func (c HashedChaos) Roll(value string) float64 {
+	sum := sha1.Sum([]byte(value))
+	// the top 53 bits fit exactly in a float64's mantissa
+	return float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53) * 100
+}
+
+func (c HashedChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	value := message.Field(r, c.By)
+	if value == "" {
+		return c.Chaos.Do(w, r)
+	}
+	chaos := c.Chaos.Matching(r).Choose(c.Roll(value))
+	if chaos == nil {
+		return w, r
+	}
+	events.Debug("Causing chaos %#v for %{by}s %{value}q", chaos, c.By, value)
+	return chaos.Do(w, r)
+}
@@ -0,0 +1,60 @@
+package chaos
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestHashed(t *testing.T) {
+	var wc WeightedChaos
+	err := yaml.Unmarshal([]byte(`
+- hashed:
+    by: messageId
+    chaos:
+      - weight: 50
+        statusCode:
+          code: 503
+`), &wc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed := 0
+	for i := 0; i < 100; i++ {
+		body := fmt.Sprintf(`{"messageId":"message-%d"}`, i)
+		var codes []int
+		for j := 0; j < 5; j++ {
+			rec := httptest.NewRecorder()
+			wc.Do(rec, httptest.NewRequest("POST", "/v1/track", strings.NewReader(body)))
+			codes = append(codes, rec.Code)
+		}
+		for _, code := range codes[1:] {
+			if code != codes[0] {
+				t.Fatalf("message-%d: expected the same fate every time; got %v", i, codes)
+			}
+		}
+		if codes[0] == 503 {
+			failed++
+		}
+	}
+	if failed < 30 || failed > 70 {
+		t.Errorf("expected about half the messages to fail; %d/100 did", failed)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	roll := func() []float64 {
+		Seed(42)
+		return []float64{Rand.Float64(), Rand.Float64(), Rand.Float64()}
+	}
+	first, second := roll(), roll()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("expected the same rolls for the same seed; got %v and %v", first, second)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package chaos
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Header lets a client pick its own chaos, e.g. `X-Chaos: statusCode=503` or
+// `X-Chaos: latency; latency=2000; jitter=500`.
+const Header = "X-Chaos"
+
+// The field set by a bare `kind=value` in the X-Chaos header.
+var headerFields = map[Kind]string{
+	StatusCode: "code",
+	Latency:    "latency",
+	Reset:      "readBody",
+	Truncate:   "bytes",
+	Throttle:   "bytesPerSecond",
+	SlowBody:   "bytesPerSecond",
+	CutBody:    "bytes",
+	Duplicate:  "copies",
+	AckFailure: "code",
+	Malformed:  "mode",
+	Redirect:   "code",
+	SizeLimit:  "max",
+}
+
+// ParseHeader parses an X-Chaos header into the chaos it asks for: a kind,
+// optionally `=` the value of its main field, followed by any other fields as
+// `; field=value`. Values are converted to the fields' types. Fields that read
+// files on the server, e.g. `bodyFile`, are rejected.
+func ParseHeader(value string) (Chaos, error) {
+	parts := strings.Split(value, ";")
+	params := make(map[interface{}]interface{})
+
+	kind, kindValue := splitParam(parts[0])
+	if kindValue != "" {
+		field, ok := headerFields[Kind(kind)]
+		if !ok {
+			return nil, fmt.Errorf("chaos `%s` takes no value; use `%s; field=value`", kind, kind)
+		}
+		params[field] = kindValue
+	}
+	for _, part := range parts[1:] {
+		field, fieldValue := splitParam(part)
+		if field == "" {
+			continue
+		}
+		params[field] = fieldValue
+	}
+
+	if field := fileField(params); field != "" {
+		return nil, fmt.Errorf("`%s` can't be set from the %s header", field, Header)
+	}
+
+	c, err := decodeItems([]map[string]interface{}{{kind: params}})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func splitParam(s string) (string, string) {
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) == 1 {
+		return strings.TrimSpace(kv[0]), ""
+	}
+	return strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+}
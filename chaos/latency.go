@@ -0,0 +1,183 @@
+package chaos
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/events"
+)
+
+// Latency distributions.
+const (
+	Uniform     = "uniform"
+	Normal      = "normal"
+	LogNormal   = "logNormal"
+	Exponential = "exponential"
+	Pareto      = "pareto"
+	Histogram   = "histogram"
+)
+
+// Delay request by some amount, in ms, drawn from `Distribution`:
+//   - uniform (the default): `Latency` plus or minus up to `Jitter`
+//   - normal: mean `Latency`, standard deviation `StdDev`
+//   - logNormal: median `Latency`, shape `Sigma`
+//   - exponential: mean `Latency`
+//   - pareto: at least `Latency`, with a long tail of shape `Alpha`
+//   - histogram: interpolated between `Percentiles`, e.g. p50, p90, p99, max
+//
+// Delays are capped at `Max`, if set, and at a day regardless.
+type LatencyChaos struct {
+	Latency      int64            `mapstructure:"latency"`
+	Jitter       int64            `mapstructure:"jitter"`
+	Distribution string           `mapstructure:"distribution"`
+	StdDev       float64          `mapstructure:"stdDev"`
+	Sigma        float64          `mapstructure:"sigma"`
+	Alpha        float64          `mapstructure:"alpha"`
+	Percentiles  map[string]int64 `mapstructure:"percentiles"`
+	Max          int64            `mapstructure:"max"`
+
+	histogram []percentile
+}
+
+// maxDelay caps delays, in ms, when `Max` isn't set.
+const maxDelay = float64(24 * time.Hour / time.Millisecond)
+
+type percentile struct {
+	p     float64
+	delay float64
+}
+
+func decodeLatency(v interface{}) (c LatencyChaos, err error) {
+	if err = decode(v, &c); err != nil {
+		return c, fmt.Errorf("invalid latency: %s", err)
+	}
+	for name, value := range map[string]float64{
+		"latency": float64(c.Latency),
+		"jitter":  float64(c.Jitter),
+		"max":     float64(c.Max),
+		"stdDev":  c.StdDev,
+		"sigma":   c.Sigma,
+	} {
+		if value < 0 {
+			return c, fmt.Errorf("latency: %s must be >= 0; is %g", name, value)
+		}
+	}
+	switch c.Distribution {
+	case "", Uniform, Normal, LogNormal, Exponential:
+	case Pareto:
+		if c.Alpha <= 0 {
+			return c, fmt.Errorf("latency: pareto alpha must be > 0")
+		}
+	case Histogram:
+		if c.histogram, err = parsePercentiles(c.Percentiles); err != nil {
+			return c, fmt.Errorf("latency: %s", err)
+		}
+	default:
+		return c, fmt.Errorf("latency: unrecognized distribution `%s`", c.Distribution)
+	}
+	return c, nil
+}
+
+// parsePercentiles turns e.g. {p50: 100, p99.9: 2000, max: 5000} into points
+// of the inverse CDF, starting from `min` (or 0).
+func parsePercentiles(percentiles map[string]int64) ([]percentile, error) {
+	if percentiles["min"] < 0 {
+		return nil, fmt.Errorf("percentile `min` must be >= 0; is %d", percentiles["min"])
+	}
+	points := []percentile{{p: 0, delay: float64(percentiles["min"])}}
+	for key, delay := range percentiles {
+		var p float64
+		switch key {
+		case "min":
+			continue
+		case "max":
+			p = 100
+		default:
+			var err error
+			p, err = strconv.ParseFloat(strings.TrimPrefix(key, "p"), 64)
+			if err != nil || !strings.HasPrefix(key, "p") || p <= 0 || p > 100 {
+				return nil, fmt.Errorf("unrecognized percentile `%s`", key)
+			}
+		}
+		if delay < 0 {
+			return nil, fmt.Errorf("percentile `%s` must be >= 0; is %d", key, delay)
+		}
+		points = append(points, percentile{p: p, delay: float64(delay)})
+	}
+	if len(points) < 2 {
+		return nil, fmt.Errorf("histogram needs at least one percentile")
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].p < points[j].p })
+	for i := 1; i < len(points); i++ {
+		if points[i].delay < points[i-1].delay {
+			return nil, fmt.Errorf("percentiles must not decrease; p%g < p%g", points[i].p, points[i-1].p)
+		}
+	}
+	return points, nil
+}
+
+func (c LatencyChaos) delay() time.Duration {
+	latency := float64(c.Latency)
+	var delay float64
+	switch c.Distribution {
+	case Normal:
+		delay = latency + Rand.NormFloat64()*c.StdDev
+	case LogNormal:
+		delay = latency * math.Exp(Rand.NormFloat64()*c.Sigma)
+	case Exponential:
+		delay = latency * Rand.ExpFloat64()
+	case Pareto:
+		delay = latency / math.Pow(1-Rand.Float64(), 1/c.Alpha)
+	case Histogram:
+		delay = c.fromHistogram(Rand.Float64() * 100)
+	default:
+		delay = latency
+		if c.Jitter > 0 {
+			delay += float64(Rand.Int63n(c.Jitter*2) - c.Jitter)
+		}
+	}
+	if c.Max > 0 && delay > float64(c.Max) {
+		delay = float64(c.Max)
+	}
+	// long tails can go past what a time.Duration holds, even to +Inf
+	if delay > maxDelay || math.IsNaN(delay) {
+		delay = maxDelay
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return time.Duration(delay * float64(time.Millisecond))
+}
+
+// fromHistogram returns the delay at percentile p, interpolating linearly
+// between the configured percentiles. Past the last one it stays flat.
+func (c LatencyChaos) fromHistogram(p float64) float64 {
+	for i := 1; i < len(c.histogram); i++ {
+		lo, hi := c.histogram[i-1], c.histogram[i]
+		if p < hi.p {
+			return lo.delay + (p-lo.p)/(hi.p-lo.p)*(hi.delay-lo.delay)
+		}
+	}
+	return c.histogram[len(c.histogram)-1].delay
+}
+
+// The delay is cut short if the client goes away, in which case the request
+// is stopped, or if the server is shutting down.
+func (c LatencyChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	delay := c.delay()
+	start := time.Now()
+	switch err := sleep(r.Context(), delay); err {
+	case nil:
+	case ErrShutdown:
+		events.Debug("[chaos]: latency cut short after %{elapsed}s of %{delay}s by shutdown", time.Since(start), delay)
+	default:
+		events.Debug("[chaos]: client disconnected after %{elapsed}s of %{delay}s latency: %{error}s", time.Since(start), delay, err)
+		return w, nil
+	}
+	return w, r
+}
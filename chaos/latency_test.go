@@ -0,0 +1,95 @@
+package chaos
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestLatencyJitter(t *testing.T) {
+	c := LatencyChaos{Latency: 1000, Jitter: 100}
+	for i := 0; i < 1000; i++ {
+		if delay := c.delay(); delay < 900*time.Millisecond || delay > 1100*time.Millisecond {
+			t.Fatalf("expected delay within 1000±100ms; got %s", delay)
+		}
+	}
+}
+
+func TestLatencyDistributions(t *testing.T) {
+	Seed(1)
+	cases := []struct {
+		config   map[interface{}]interface{}
+		min, max time.Duration // bounds on every delay
+		p50      time.Duration // roughly
+	}{
+		{
+			config: map[interface{}]interface{}{"distribution": "normal", "latency": 100, "stdDev": 10},
+			min:    0, max: time.Second, p50: 100 * time.Millisecond,
+		},
+		{
+			config: map[interface{}]interface{}{"distribution": "logNormal", "latency": 100, "sigma": 0.5},
+			min:    0, max: time.Hour, p50: 100 * time.Millisecond,
+		},
+		{
+			config: map[interface{}]interface{}{"distribution": "pareto", "latency": 100, "alpha": 1, "max": 5000},
+			min:    100 * time.Millisecond, max: 5 * time.Second, p50: 200 * time.Millisecond,
+		},
+		{
+			config: map[interface{}]interface{}{"distribution": "histogram", "percentiles": map[interface{}]interface{}{
+				"p50": 100, "p90": 400, "p99": 2000, "max": 10000,
+			}},
+			min: 0, max: 10 * time.Second, p50: 100 * time.Millisecond,
+		},
+	}
+
+	for _, tc := range cases {
+		c, err := decodeLatency(tc.config)
+		if err != nil {
+			t.Fatalf("%v: %s", tc.config, err)
+		}
+		delays := make([]time.Duration, 10000)
+		for i := range delays {
+			delays[i] = c.delay()
+			if delays[i] < tc.min || delays[i] > tc.max {
+				t.Fatalf("%v: delay %s out of [%s, %s]", tc.config, delays[i], tc.min, tc.max)
+			}
+		}
+		sort.Slice(delays, func(i, j int) bool { return delays[i] < delays[j] })
+		if p50 := delays[len(delays)/2]; p50 < tc.p50*9/10 || p50 > tc.p50*11/10 {
+			t.Errorf("%v: expected p50 around %s; got %s", tc.config, tc.p50, p50)
+		}
+	}
+}
+
+func TestLatencyInvalid(t *testing.T) {
+	for _, config := range []map[interface{}]interface{}{
+		{"distribution": "bimodal"},
+		{"distribution": "pareto", "latency": 100},
+		{"distribution": "histogram"},
+		{"distribution": "histogram", "percentiles": map[interface{}]interface{}{"p50": 500, "p90": 100}},
+		{"distribution": "histogram", "percentiles": map[interface{}]interface{}{"median": 500}},
+		{"latency": -100},
+		{"latency": 100, "jitter": -10},
+		{"latency": 100, "max": -1},
+		{"distribution": "normal", "latency": 100, "stdDev": -10},
+		{"distribution": "logNormal", "latency": 100, "sigma": -1},
+		{"distribution": "histogram", "percentiles": map[interface{}]interface{}{"min": -5, "p50": 100}},
+	} {
+		if _, err := decodeLatency(config); err == nil {
+			t.Errorf("%v: expected error", config)
+		}
+	}
+}
+
+func TestLatencyParetoTail(t *testing.T) {
+	Seed(1)
+	c, err := decodeLatency(map[interface{}]interface{}{"distribution": "pareto", "latency": 100, "alpha": 0.05})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100000; i++ {
+		if delay := c.delay(); delay < 100*time.Millisecond || delay > 24*time.Hour {
+			t.Fatalf("expected delay within [100ms, 24h]; got %s", delay)
+		}
+	}
+}
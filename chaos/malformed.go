@@ -0,0 +1,140 @@
+package chaos
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Ways of malforming a response.
+const (
+	MalformedInvalid     = "invalid"
+	MalformedHTML        = "html"
+	MalformedEmpty       = "empty"
+	MalformedContentType = "contentType"
+)
+
+// Let the downstream handler run, then mangle its response according to
+// `Mode`:
+//   - invalid (the default): a body that doesn't parse as the handler's
+//     Content-Type, i.e. cut off JSON, or something other than a GIF for the
+//     pixel routes
+//   - html: an HTML error page, as sent by a load balancer or proxy; the status
+//     defaults to 502
+//   - empty: no body at all, but still claiming to be JSON
+//   - contentType: the handler's body, but sent as `ContentType` (text/html if
+//     unset)
+//
+// `Code`, if set, replaces the handler's status code, and `Body` the body.
+type MalformedChaos struct {
+	Mode        string `mapstructure:"mode"`
+	Code        int    `mapstructure:"code"`
+	ContentType string `mapstructure:"contentType"`
+	Body        []byte `mapstructure:"body"`
+}
+
+func decodeMalformed(v interface{}) (c MalformedChaos, err error) {
+	if err = decode(v, &c); err != nil {
+		return c, fmt.Errorf("invalid malformed: %s", err)
+	}
+	if c.Code != 0 {
+		if err = checkCode(Malformed, c.Code); err != nil {
+			return c, err
+		}
+	}
+	switch c.Mode {
+	case "":
+		c.Mode = MalformedInvalid
+	case MalformedInvalid, MalformedHTML, MalformedEmpty, MalformedContentType:
+	default:
+		return c, fmt.Errorf("malformed: unknown mode `%s`", c.Mode)
+	}
+	return c, nil
+}
+
+func (c MalformedChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	return &malformedWriter{ResponseWriter: w, chaos: c}, r
+}
+
+// response returns the status, Content-Type and body to send in place of the
+// handler's. A nil body means the handler's body is kept.
+func (c MalformedChaos) response(code int, contentType string) (int, string, []byte) {
+	var body []byte
+	if c.Code != 0 {
+		code = c.Code
+	} else if c.Mode == MalformedHTML {
+		code = http.StatusBadGateway
+	}
+	switch c.Mode {
+	case MalformedHTML:
+		contentType = "text/html"
+		body = errorPage(code)
+	case MalformedEmpty:
+		contentType = "application/json"
+		body = []byte{}
+	case MalformedContentType:
+		contentType = c.ContentType
+		if contentType == "" {
+			contentType = "text/html"
+		}
+	default:
+		if strings.HasPrefix(contentType, "image/") {
+			body = []byte("not an image")
+		} else {
+			body = []byte(`{"success":`)
+		}
+	}
+	if c.Body != nil {
+		body = c.Body
+	}
+	return code, contentType, body
+}
+
+// errorPage is an HTML error page like the ones proxies send.
+func errorPage(code int) []byte {
+	status := fmt.Sprintf("%d %s", code, http.StatusText(code))
+	return []byte("<html>\r\n<head><title>" + status + "</title></head>\r\n" +
+		"<body>\r\n<center><h1>" + status + "</h1></center>\r\n</body>\r\n</html>\r\n")
+}
+
+// malformedWriter swaps in the malformed response once the downstream handler
+// has set its headers and starts writing, and drops the handler's body unless
+// it's kept.
+type malformedWriter struct {
+	http.ResponseWriter
+	chaos       MalformedChaos
+	wroteHeader bool
+	keepBody    bool
+}
+
+func (m *malformedWriter) WriteHeader(code int) {
+	if m.wroteHeader {
+		return
+	}
+	m.wroteHeader = true
+
+	header := m.ResponseWriter.Header()
+	code, contentType, body := m.chaos.response(code, header.Get("Content-Type"))
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	m.keepBody = body == nil
+	if !m.keepBody {
+		header.Del("Content-Length")
+		header.Del("Content-Encoding")
+	}
+	m.ResponseWriter.WriteHeader(code)
+	if !m.keepBody {
+		m.ResponseWriter.Write(body)
+	}
+}
+
+func (m *malformedWriter) Write(b []byte) (int, error) {
+	if !m.wroteHeader {
+		m.WriteHeader(http.StatusOK)
+	}
+	if m.keepBody {
+		return m.ResponseWriter.Write(b)
+	}
+	return len(b), nil
+}
@@ -0,0 +1,59 @@
+package chaos
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func jsonHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"success":true}`))
+}
+
+func TestMalformed(t *testing.T) {
+	cases := []struct {
+		config      map[interface{}]interface{}
+		code        int
+		contentType string
+		body        string
+	}{
+		{map[interface{}]interface{}{}, 200, "application/json", `{"success":`},
+		{map[interface{}]interface{}{"mode": "html"}, 502, "text/html", "<html>\r\n<head><title>502 Bad Gateway</title></head>"},
+		{map[interface{}]interface{}{"mode": "html", "code": 504}, 504, "text/html", "<html>\r\n<head><title>504 Gateway Timeout</title></head>"},
+		{map[interface{}]interface{}{"mode": "empty"}, 200, "application/json", ""},
+		{map[interface{}]interface{}{"mode": "contentType"}, 200, "text/html", `{"success":true}`},
+		{map[interface{}]interface{}{"mode": "contentType", "contentType": "text/plain"}, 200, "text/plain", `{"success":true}`},
+		{map[interface{}]interface{}{"body": "{success: true}"}, 200, "application/json", `{success: true}`},
+	}
+	for _, tc := range cases {
+		c, err := decodeMalformed(tc.config)
+		if err != nil {
+			t.Fatalf("%v: %s", tc.config, err)
+		}
+		rec := httptest.NewRecorder()
+		jsonHandler(c.Do(rec, httptest.NewRequest("POST", "/v1/track", nil)))
+
+		if rec.Code != tc.code {
+			t.Errorf("%v: expected %d; got %d", tc.config, tc.code, rec.Code)
+		}
+		if contentType := rec.Header().Get("Content-Type"); contentType != tc.contentType {
+			t.Errorf("%v: expected Content-Type %q; got %q", tc.config, tc.contentType, contentType)
+		}
+		if body := rec.Body.String(); !strings.HasPrefix(body, tc.body) || tc.body == "" && body != "" {
+			t.Errorf("%v: expected body %q; got %q", tc.config, tc.body, body)
+		}
+		if tc.config["mode"] != "contentType" && json.Valid(rec.Body.Bytes()) && rec.Body.Len() > 0 {
+			t.Errorf("%v: expected body not to be valid JSON; got %q", tc.config, rec.Body.String())
+		}
+	}
+}
+
+func TestMalformedInvalidMode(t *testing.T) {
+	if _, err := decodeMalformed(map[interface{}]interface{}{"mode": "garbled"}); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
@@ -0,0 +1,123 @@
+package chaos
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/segmentio/tracking-api-chaos/client"
+	"github.com/segmentio/tracking-api-chaos/pixel"
+	"github.com/segmentio/tracking-api-chaos/server"
+)
+
+// Match limits a chaos to some requests. Every field that is set must match:
+// `Path` is a glob (see path.Match), `PathRegex` a regular expression,
+// `Methods` and `Channels` (server, client or pixel) are alternatives, and
+// every entry in `Headers` and `Query` must equal the request's value.
+//
+// Every item that applies to a request shares one 0-100 roll, so an item's
+// weight is its chance only if the items before it that also apply leave it
+// room. Weights of items with the same match, plus those of unmatched items,
+// must add up to at most 100; overlapping but different matches can't be
+// checked, so it's up to the config to keep their weights in bounds.
+type Match struct {
+	Path      string            `mapstructure:"path"`
+	PathRegex string            `mapstructure:"pathRegex"`
+	Methods   []string          `mapstructure:"methods"`
+	Channels  []string          `mapstructure:"channels"`
+	Headers   map[string]string `mapstructure:"headers"`
+	Query     map[string]string `mapstructure:"query"`
+
+	pathRegex *regexp.Regexp
+}
+
+// decodeMatch decodes and validates a `match` block, appending any problems
+// to err. The returned *Match is nil if the block is invalid.
+func decodeMatch(v interface{}, err error) (*Match, error) {
+	match := &Match{}
+	if decodeErr := decode(v, match); decodeErr != nil {
+		return nil, multierror.Append(err, fmt.Errorf("invalid match %s: %s", v, decodeErr))
+	}
+	if _, globErr := path.Match(match.Path, ""); globErr != nil {
+		return nil, multierror.Append(err, fmt.Errorf("invalid match path `%s`: %s", match.Path, globErr))
+	}
+	if match.PathRegex != "" {
+		var reErr error
+		if match.pathRegex, reErr = regexp.Compile(match.PathRegex); reErr != nil {
+			return nil, multierror.Append(err, fmt.Errorf("invalid match pathRegex `%s`: %s", match.PathRegex, reErr))
+		}
+	}
+	for _, channel := range match.Channels {
+		switch channel {
+		case "server", "client", "pixel":
+		default:
+			return nil, multierror.Append(err, fmt.Errorf("unrecognized match channel `%s`", channel))
+		}
+	}
+	return match, err
+}
+
+// Same returns whether m and o are the same match, and so match the same
+// requests.
+func (m *Match) Same(o *Match) bool {
+	a, b := *m, *o
+	a.pathRegex, b.pathRegex = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+func (m *Match) Matches(r *http.Request) bool {
+	if m.Path != "" {
+		if ok, _ := path.Match(m.Path, r.URL.Path); !ok {
+			return false
+		}
+	}
+	if m.pathRegex != nil && !m.pathRegex.MatchString(r.URL.Path) {
+		return false
+	}
+	if len(m.Methods) > 0 && !containsFold(m.Methods, r.Method) {
+		return false
+	}
+	if len(m.Channels) > 0 && !containsFold(m.Channels, Channel(r.URL.Path)) {
+		return false
+	}
+	for name, value := range m.Headers {
+		if r.Header.Get(name) != value {
+			return false
+		}
+	}
+	if len(m.Query) > 0 {
+		query := r.URL.Query()
+		for name, value := range m.Query {
+			if query.Get(name) != value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Channel returns which library channel serves path, going by each package's
+// Routes, or "" for anything else.
+func Channel(path string) string {
+	if _, ok := pixel.Routes[path]; ok {
+		return "pixel"
+	} else if _, ok := server.Routes[path]; ok {
+		return "server"
+	} else if _, ok := client.Routes[path]; ok {
+		return "client"
+	}
+	return ""
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
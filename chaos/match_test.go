@@ -0,0 +1,95 @@
+package chaos
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestMatch(t *testing.T) {
+	var wc WeightedChaos
+	err := yaml.Unmarshal([]byte(`
+- weight: 100
+  match:
+    path: /v1/b*
+    methods: [post]
+    channels: [server]
+  statusCode:
+    code: 503
+- weight: 100
+  match:
+    pathRegex: ^/v1/pixel/
+    query:
+      debug: "1"
+  statusCode:
+    code: 500
+`), &wc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		method, target string
+		matching       int
+	}{
+		{"POST", "/v1/batch", 1},
+		{"GET", "/v1/batch", 0},
+		{"POST", "/v1/b", 0}, // client channel
+		{"GET", "/v1/pixel/track?debug=1", 1},
+		{"GET", "/v1/pixel/track", 0},
+		{"GET", "/internal/health", 0},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest(tc.method, tc.target, nil)
+		if matching := wc.Matching(r); len(matching) != tc.matching {
+			t.Errorf("%s %s: expected %d matching; got %d", tc.method, tc.target, tc.matching, len(matching))
+		}
+	}
+}
+
+func TestMatchInvalid(t *testing.T) {
+	var wc WeightedChaos
+	err := yaml.Unmarshal([]byte(`
+- match:
+    channels: [mobile]
+  statusCode:
+    code: 503
+`), &wc)
+	if err == nil {
+		t.Error("expected error for unknown channel")
+	}
+}
+
+func TestMatchWeights(t *testing.T) {
+	var wc WeightedChaos
+	err := yaml.Unmarshal([]byte(`
+- weight: 80
+  match:
+    path: /v1/batch
+  statusCode:
+    code: 503
+- weight: 80
+  match:
+    path: /v1/batch
+  statusCode:
+    code: 500
+`), &wc)
+	if err == nil {
+		t.Error("expected error for items with the same match over 100")
+	}
+
+	err = yaml.Unmarshal([]byte(`
+- weight: 50
+  statusCode:
+    code: 429
+- weight: 60
+  match:
+    path: /v1/batch
+  statusCode:
+    code: 503
+`), &wc)
+	if err == nil {
+		t.Error("expected error for a matched item and unmatched items over 100")
+	}
+}
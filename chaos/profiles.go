@@ -0,0 +1,51 @@
+package chaos
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/segmentio/events"
+	"github.com/segmentio/tracking-api-chaos/message"
+)
+
+// Give each writeKey (see message.WriteKey) its own chaos, so several apps can
+// share a deployment. Requests with an unknown or missing writeKey get
+// `Default`. Finding a writeKey in the body reads it up to that field (or all
+// of it, if there is none), so body chaos such as slowBody, cutBody and reset
+// only acts on the rest.
+type ProfilesChaos struct {
+	Default   WeightedChaos
+	WriteKeys map[string]WeightedChaos
+}
+
+func decodeProfiles(v interface{}) (c ProfilesChaos, err error) {
+	var raw struct {
+		Default   interface{}            `mapstructure:"default"`
+		WriteKeys map[string]interface{} `mapstructure:"writeKeys"`
+	}
+	if err = decode(v, &raw); err != nil {
+		return c, fmt.Errorf("invalid profiles: %s", err)
+	}
+	if raw.Default != nil {
+		if c.Default, err = decodeWeightedChaos(raw.Default); err != nil {
+			return c, fmt.Errorf("profile `default`: %s", err)
+		}
+	}
+	c.WriteKeys = make(map[string]WeightedChaos, len(raw.WriteKeys))
+	for writeKey, profile := range raw.WriteKeys {
+		if c.WriteKeys[writeKey], err = decodeWeightedChaos(profile); err != nil {
+			return c, fmt.Errorf("profile `%s`: %s", writeKey, err)
+		}
+	}
+	return c, nil
+}
+
+func (c ProfilesChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	writeKey := message.WriteKey(r)
+	profile, ok := c.WriteKeys[writeKey]
+	if !ok {
+		profile = c.Default
+	}
+	events.Debug("[chaos]: writeKey %{writeKey}q has its own profile: %{ok}t", writeKey, ok)
+	return profile.Do(w, r)
+}
@@ -0,0 +1,45 @@
+package chaos
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestProfiles(t *testing.T) {
+	var wc WeightedChaos
+	err := yaml.Unmarshal([]byte(`
+- profiles:
+    default:
+      - statusCode:
+          code: 500
+    writeKeys:
+      team-a:
+        - statusCode:
+            code: 503
+      team-b: []
+`), &wc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		writeKey string
+		code     int
+	}{
+		{"team-a", 503},
+		{"team-b", 200},
+		{"unknown", 500},
+		{"", 500},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/v1/track", strings.NewReader(`{"writeKey":"`+tc.writeKey+`"}`))
+		wc.Do(rec, req)
+		if rec.Code != tc.code {
+			t.Errorf("writeKey %q: expected %d; got %d", tc.writeKey, tc.code, rec.Code)
+		}
+	}
+}
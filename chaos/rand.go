@@ -0,0 +1,36 @@
+package chaos
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Rand is the source of every random decision chaos makes; see Seed to make a
+// run's decisions reproducible. Its source is locked, so every method is safe
+// for concurrent use except Read, which keeps state of its own.
+var Rand = rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano())})
+
+// Seed reseeds Rand.
+func Seed(seed int64) {
+	Rand.Seed(seed)
+}
+
+// lockedSource makes a rand.Source safe for concurrent use, like the one
+// behind the math/rand top level functions.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
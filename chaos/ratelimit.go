@@ -0,0 +1,146 @@
+package chaos
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/events"
+	"github.com/segmentio/tracking-api-chaos/message"
+)
+
+// How often buckets that have filled back up are forgotten.
+const rateLimitPruneInterval = time.Minute
+
+// Rate limit requests with a token bucket holding up to `Burst` requests and
+// refilling at `Rate` requests per second. There is a bucket per `By`:
+// "writeKey", "ip" or "global" (the default). Requests over the limit get
+// `Code` (429 if unset) and `Body`, with a Retry-After header, and never reach
+// the handler. Every response carries X-RateLimit-* headers.
+type RateLimitChaos struct {
+	Rate  float64 `mapstructure:"rate"`
+	Burst float64 `mapstructure:"burst"`
+	By    string  `mapstructure:"by"`
+	Code  int     `mapstructure:"code"`
+	Body  []byte  `mapstructure:"body"`
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastPrune time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func decodeRateLimit(v interface{}) (*RateLimitChaos, error) {
+	c := &RateLimitChaos{}
+	if err := decode(v, c); err != nil {
+		return nil, fmt.Errorf("invalid rateLimit: %s", err)
+	}
+	if c.Rate <= 0 {
+		return nil, fmt.Errorf("rateLimit: rate must be > 0")
+	}
+	switch c.By {
+	case "":
+		c.By = "global"
+	case "global", "writeKey", "ip":
+	default:
+		return nil, fmt.Errorf("rateLimit: unrecognized by `%s`", c.By)
+	}
+	if c.Burst < 1 {
+		c.Burst = math.Max(1, c.Rate)
+	}
+	if c.Code == 0 {
+		c.Code = http.StatusTooManyRequests
+	}
+	if err := checkCode(RateLimit, c.Code); err != nil {
+		return nil, err
+	}
+	c.buckets = make(map[string]*bucket)
+	return c, nil
+}
+
+func (c *RateLimitChaos) key(r *http.Request) string {
+	switch c.By {
+	case "writeKey":
+		return message.WriteKey(r)
+	case "ip":
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return r.RemoteAddr
+		}
+		return host
+	default:
+		return ""
+	}
+}
+
+// Take takes a token from key's bucket at t. It returns how many tokens are
+// left and, if there were none to take, how long until there is one.
+func (c *RateLimitChaos) Take(key string, t time.Time) (remaining float64, wait time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if t.Sub(c.lastPrune) > rateLimitPruneInterval {
+		c.prune(t)
+	}
+
+	b, ok := c.buckets[key]
+	if !ok {
+		b = &bucket{tokens: c.Burst, last: t}
+		c.buckets[key] = b
+	}
+	b.tokens = math.Min(c.Burst, b.tokens+t.Sub(b.last).Seconds()*c.Rate)
+	b.last = t
+
+	if b.tokens < 1 {
+		return b.tokens, time.Duration((1 - b.tokens) / c.Rate * float64(time.Second))
+	}
+	b.tokens--
+	return b.tokens, 0
+}
+
+// prune forgets buckets that would be full by now anyway.
+func (c *RateLimitChaos) prune(t time.Time) {
+	for key, b := range c.buckets {
+		if b.tokens+t.Sub(b.last).Seconds()*c.Rate >= c.Burst {
+			delete(c.buckets, key)
+		}
+	}
+	c.lastPrune = t
+}
+
+func (c *RateLimitChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	t := now()
+	key := c.key(r)
+	remaining, wait := c.Take(key, t)
+
+	// time until the bucket is full again
+	reset := time.Duration((c.Burst - remaining) / c.Rate * float64(time.Second))
+	header := w.Header()
+	header.Set("X-RateLimit-Limit", strconv.FormatFloat(c.Burst, 'f', -1, 64))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(int(remaining)))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(t.Add(reset).Unix(), 10))
+
+	if wait == 0 {
+		return w, r
+	}
+
+	events.Debug("[chaos]: rate limited %{key}q for %{wait}s", key, wait)
+	header.Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	w.WriteHeader(c.Code)
+	if c.Body != nil {
+		w.Write(c.Body)
+	}
+	return w, nil
+}
@@ -0,0 +1,54 @@
+package chaos
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	c, err := decodeRateLimit(map[interface{}]interface{}{
+		"rate":  2,
+		"burst": 2,
+		"by":    "ip",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	defer func() { now = time.Now }()
+	now = func() time.Time { return start }
+
+	request := func(ip string) (int, string) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/v1/track", nil)
+		req.RemoteAddr = ip + ":1234"
+		_, req = c.Do(rec, req)
+		if req == nil && rec.Code == 200 {
+			t.Errorf("%s: stopped request without responding", ip)
+		}
+		return rec.Code, rec.Header().Get("Retry-After")
+	}
+
+	for i := 0; i < 2; i++ {
+		if code, _ := request("10.0.0.1"); code != 200 {
+			t.Errorf("request %d: expected to be allowed; got %d", i, code)
+		}
+	}
+	if code, retryAfter := request("10.0.0.1"); code != 429 || retryAfter != "1" {
+		t.Errorf("expected 429 with Retry-After 1; got %d, %q", code, retryAfter)
+	}
+	if code, _ := request("10.0.0.2"); code != 200 {
+		t.Errorf("expected another ip to have its own bucket; got %d", code)
+	}
+
+	// 2 requests per second refills a token every 500ms
+	now = func() time.Time { return start.Add(500 * time.Millisecond) }
+	if code, _ := request("10.0.0.1"); code != 200 {
+		t.Errorf("expected token to be refilled; got %d", code)
+	}
+	if code, _ := request("10.0.0.1"); code != 429 {
+		t.Errorf("expected 429; got %d", code)
+	}
+}
@@ -0,0 +1,91 @@
+package chaos
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/segmentio/events"
+)
+
+// HopsParam is the query parameter counting how many redirects a client has
+// followed. It's removed before the request reaches the downstream handler.
+const HopsParam = "chaosRedirectHops"
+
+// Redirect to `Location` with `Code` (302 if unset). `Location` may be a path
+// on this server, another server (e.g. another port), or left unset to redirect
+// to the request's own URL, making a loop. The query string is kept unless
+// `Location` has its own. Once a client has followed more than `MaxHops`
+// redirects, if set, it's answered with a 508 instead, so tests can check the
+// client gives up before then.
+type RedirectChaos struct {
+	Code     int    `mapstructure:"code"`
+	Location string `mapstructure:"location"`
+	MaxHops  int    `mapstructure:"maxHops"`
+
+	location *url.URL
+}
+
+func decodeRedirect(v interface{}) (c RedirectChaos, err error) {
+	if err = decode(v, &c); err != nil {
+		return c, fmt.Errorf("invalid redirect: %s", err)
+	}
+	switch c.Code {
+	case 0:
+		c.Code = http.StatusFound
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return c, fmt.Errorf("redirect: code must be a redirect; is %d", c.Code)
+	}
+	if c.location, err = url.Parse(c.Location); err != nil {
+		return c, fmt.Errorf("redirect: invalid location: %s", err)
+	}
+	return c, nil
+}
+
+func (c RedirectChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	hops := Hops(r)
+	if c.MaxHops > 0 && hops > c.MaxHops {
+		events.Log("redirect loop: %{remoteAddr}s followed %{hops}d redirects to %{path}s", r.RemoteAddr, hops, r.URL.Path)
+		http.Error(w, fmt.Sprintf("followed %d redirects", hops), http.StatusLoopDetected)
+		return w, nil
+	}
+
+	location := c.location
+	if location == nil {
+		location, _ = url.Parse(c.Location)
+	}
+	location = r.URL.ResolveReference(location)
+	query := location.Query()
+	if location.RawQuery == "" {
+		query = r.URL.Query()
+	}
+	query.Set(HopsParam, strconv.Itoa(hops+1))
+	location.RawQuery = query.Encode()
+
+	code := c.Code
+	if code == 0 {
+		code = http.StatusFound
+	}
+	w.Header().Set("Location", location.String())
+	w.WriteHeader(code)
+	return w, nil
+}
+
+// Hops returns how many redirects the client has followed to get to r.
+func Hops(r *http.Request) int {
+	hops, _ := strconv.Atoi(r.URL.Query().Get(HopsParam))
+	return hops
+}
+
+// StripHops removes the hop count from r's query string.
+func StripHops(r *http.Request) {
+	query := r.URL.Query()
+	if _, ok := query[HopsParam]; !ok {
+		return
+	}
+	query.Del(HopsParam)
+	r.URL.RawQuery = query.Encode()
+}
@@ -0,0 +1,50 @@
+package chaos
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirect(t *testing.T) {
+	cases := []struct {
+		config   map[interface{}]interface{}
+		url      string
+		code     int
+		location string
+	}{
+		{map[interface{}]interface{}{}, "/v1/pixel/track?data=abc", 302, "/v1/pixel/track?chaosRedirectHops=1&data=abc"},
+		{map[interface{}]interface{}{"code": 307, "location": "/v1/batch"}, "/v1/track", 307, "/v1/batch?chaosRedirectHops=1"},
+		{map[interface{}]interface{}{"code": 308, "location": "http://localhost:8081/v1/track?x=y"}, "/v1/track?chaosRedirectHops=2", 308, "http://localhost:8081/v1/track?chaosRedirectHops=3&x=y"},
+		{map[interface{}]interface{}{"maxHops": 3}, "/v1/track?chaosRedirectHops=3", 302, "/v1/track?chaosRedirectHops=4"},
+		{map[interface{}]interface{}{"maxHops": 3}, "/v1/track?chaosRedirectHops=4", 508, ""},
+	}
+	for _, tc := range cases {
+		c, err := decodeRedirect(tc.config)
+		if err != nil {
+			t.Fatalf("%v: %s", tc.config, err)
+		}
+		rec := httptest.NewRecorder()
+		_, req := c.Do(rec, httptest.NewRequest("POST", tc.url, nil))
+		if req != nil {
+			t.Errorf("%v: expected request to be stopped", tc.config)
+		}
+		if rec.Code != tc.code {
+			t.Errorf("%v: expected %d; got %d", tc.config, tc.code, rec.Code)
+		}
+		if location := rec.Header().Get("Location"); location != tc.location {
+			t.Errorf("%v: expected Location %q; got %q", tc.config, tc.location, location)
+		}
+	}
+
+	if _, err := decodeRedirect(map[interface{}]interface{}{"code": 200}); err == nil {
+		t.Error("expected error for a non-redirect code")
+	}
+}
+
+func TestStripHops(t *testing.T) {
+	req := httptest.NewRequest("GET", "/v1/pixel/track?chaosRedirectHops=2&userId=user-id", nil)
+	StripHops(req)
+	if req.URL.RawQuery != "userId=user-id" {
+		t.Errorf("expected hop count to be removed; got %q", req.URL.RawQuery)
+	}
+}
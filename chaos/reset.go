@@ -0,0 +1,58 @@
+package chaos
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/segmentio/events"
+)
+
+// Kill the connection with a TCP RST instead of responding. If `ReadBody` is
+// set the request body is read in full first, so the client only sees the
+// reset after it has finished sending.
+type ResetChaos struct {
+	ReadBody bool `mapstructure:"readBody"`
+}
+
+func decodeReset(v interface{}) (c ResetChaos, err error) {
+	if err = decode(v, &c); err != nil {
+		return c, fmt.Errorf("invalid reset: %s", err)
+	}
+	return c, nil
+}
+
+func (c ResetChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	if c.ReadBody {
+		io.Copy(ioutil.Discard, r.Body)
+	}
+	reset(hijack(w))
+	return w, nil
+}
+
+// hijack takes over the connection behind w, flushing anything already
+// written. When w can't be hijacked (e.g. HTTP/2) the handler is aborted
+// instead, which is the closest net/http lets us get.
+func hijack(w http.ResponseWriter) net.Conn {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		events.Debug("[chaos]: %T is not a http.Hijacker; aborting handler", w)
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		events.Log("[chaos]: hijacking connection: %{error}s", err)
+		panic(http.ErrAbortHandler)
+	}
+	return conn
+}
+
+func reset(conn net.Conn) {
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		// with a linger of 0, Close discards unsent data and sends RST, not FIN
+		tcp.SetLinger(0)
+	}
+	conn.Close()
+}
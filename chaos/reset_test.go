@@ -0,0 +1,35 @@
+package chaos
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReset(t *testing.T) {
+	for _, readBody := range []bool{false, true} {
+		var read bytes.Buffer
+		handled := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer close(handled)
+			r.Body = ioutil.NopCloser(io.TeeReader(r.Body, &read))
+			if _, r = (ResetChaos{ReadBody: readBody}).Do(w, r); r != nil {
+				t.Errorf("readBody=%t: expected request to be stopped", readBody)
+			}
+		}))
+
+		_, err := http.Post(srv.URL, "application/json", strings.NewReader(`{"userId":"user-id"}`))
+		if err == nil {
+			t.Errorf("readBody=%t: expected connection error", readBody)
+		}
+		<-handled
+		if readBody && read.String() != `{"userId":"user-id"}` {
+			t.Errorf("readBody=%t: body not read before reset; read %q", readBody, read.String())
+		}
+		srv.Close()
+	}
+}
@@ -0,0 +1,84 @@
+package chaos
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/segmentio/events"
+)
+
+// now is patched in tests.
+var now = time.Now
+
+// Run through `Phases` one after the other, starting when the config is
+// loaded, each applying its own chaos for its `Duration` (ms). With `Loop` the
+// schedule starts over after the last phase; without it there is no chaos
+// from then on.
+type ScheduleChaos struct {
+	Loop   bool
+	Phases []SchedulePhase
+	start  time.Time
+}
+
+type SchedulePhase struct {
+	Duration time.Duration
+	Chaos    WeightedChaos
+}
+
+func decodeSchedule(v interface{}) (c ScheduleChaos, err error) {
+	var raw struct {
+		Loop   bool `mapstructure:"loop"`
+		Phases []struct {
+			Duration int64       `mapstructure:"duration"`
+			Chaos    interface{} `mapstructure:"chaos"`
+		} `mapstructure:"phases"`
+	}
+	if err = decode(v, &raw); err != nil {
+		return c, fmt.Errorf("invalid schedule: %s", err)
+	}
+	c.Loop = raw.Loop
+	for i, rawPhase := range raw.Phases {
+		if rawPhase.Duration <= 0 {
+			return c, fmt.Errorf("schedule phase %d: duration must be > 0", i)
+		}
+		phase := SchedulePhase{Duration: time.Duration(rawPhase.Duration) * time.Millisecond}
+		if rawPhase.Chaos != nil {
+			if phase.Chaos, err = decodeWeightedChaos(rawPhase.Chaos); err != nil {
+				return c, fmt.Errorf("schedule phase %d: %s", i, err)
+			}
+		}
+		c.Phases = append(c.Phases, phase)
+	}
+	c.start = now()
+	return c, nil
+}
+
+// Phase returns the index of the phase in effect at t, or -1 if the schedule
+// is over.
+func (c ScheduleChaos) Phase(t time.Time) int {
+	var total time.Duration
+	for _, phase := range c.Phases {
+		total += phase.Duration
+	}
+	elapsed := t.Sub(c.start)
+	if c.Loop && total > 0 {
+		elapsed %= total
+	}
+	for i, phase := range c.Phases {
+		if elapsed < phase.Duration {
+			return i
+		}
+		elapsed -= phase.Duration
+	}
+	return -1
+}
+
+func (c ScheduleChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	i := c.Phase(now())
+	if i < 0 {
+		return w, r
+	}
+	events.Debug("[chaos]: in schedule phase %{phase}d", i)
+	return c.Phases[i].Chaos.Do(w, r)
+}
@@ -0,0 +1,57 @@
+package chaos
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestSchedule(t *testing.T) {
+	start := time.Now()
+	defer func() { now = time.Now }()
+	now = func() time.Time { return start }
+
+	for _, loop := range []bool{false, true} {
+		var wc WeightedChaos
+		config := `
+- schedule:
+    loop: ` + fmt.Sprint(loop) + `
+    phases:
+      - duration: 60000
+      - duration: 60000
+        chaos:
+          - statusCode:
+              code: 503
+      - duration: 180000
+        chaos:
+          - weight: 20
+            latency:
+              latency: 5000
+`
+		if err := yaml.Unmarshal([]byte(config), &wc); err != nil {
+			t.Fatal(err)
+		}
+		schedule := wc[0].Chaos.(ScheduleChaos)
+
+		cases := []struct {
+			at    time.Duration
+			phase int
+		}{
+			{0, 0},
+			{59 * time.Second, 0},
+			{60 * time.Second, 1},
+			{119 * time.Second, 1},
+			{120 * time.Second, 2},
+			{299 * time.Second, 2},
+			{300 * time.Second, map[bool]int{false: -1, true: 0}[loop]},
+			{370 * time.Second, map[bool]int{false: -1, true: 1}[loop]},
+		}
+		for _, tc := range cases {
+			if phase := schedule.Phase(start.Add(tc.at)); phase != tc.phase {
+				t.Errorf("loop=%t at %s: expected phase %d; got %d", loop, tc.at, tc.phase, phase)
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package chaos
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Apply each step in turn, e.g. latency and then a status code. A step is a
+// chaos item like any other; its weight is the chance it is applied (100 by
+// default). A step that stops the request ends the sequence.
+type SequenceChaos []WeightedChaos
+
+func decodeSequence(v interface{}) (SequenceChaos, error) {
+	itemsmap, err := decodeItemsMap(v)
+	if err != nil {
+		return nil, fmt.Errorf("sequence: %s", err)
+	}
+	c := make(SequenceChaos, 0, len(itemsmap))
+	for i := range itemsmap {
+		step, err := decodeItems(itemsmap[i : i+1])
+		if err != nil {
+			return nil, fmt.Errorf("sequence step %d: %s", i, err)
+		}
+		c = append(c, step)
+	}
+	return c, nil
+}
+
+func (c SequenceChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	for _, step := range c {
+		if w, r = step.Do(w, r); r == nil {
+			break
+		}
+	}
+	return w, r
+}
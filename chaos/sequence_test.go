@@ -0,0 +1,40 @@
+package chaos
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestSequence(t *testing.T) {
+	var wc WeightedChaos
+	err := yaml.Unmarshal([]byte(`
+- sequence:
+    - latency:
+        latency: 50
+    - weighted:
+        - weight: 100
+          statusCode:
+            code: 503
+    - weight: 0
+      statusCode:
+        code: 500
+`), &wc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/v1/track", nil)
+	start := time.Now()
+	wc.Do(rec, req)
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected latency step to apply; took %s", elapsed)
+	}
+	if rec.Code != 503 {
+		t.Errorf("expected 503 from nested weighted step; got %d", rec.Code)
+	}
+}
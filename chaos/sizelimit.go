@@ -0,0 +1,41 @@
+package chaos
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/segmentio/tracking-api-chaos/message"
+)
+
+// Lower the request's size limit to `Max` bytes, or, if `Min` is set too, to
+// somewhere between `Min` and `Max`, so that requests over it get a 413, except
+// on the pixel routes, which still get their GIF. Limits higher than the
+// configured one have no effect.
+type SizeLimitChaos struct {
+	Min int64 `mapstructure:"min"`
+	Max int64 `mapstructure:"max"`
+}
+
+func decodeSizeLimit(v interface{}) (c SizeLimitChaos, err error) {
+	if err = decode(v, &c); err != nil {
+		return c, fmt.Errorf("invalid sizeLimit: %s", err)
+	}
+	if c.Max <= 0 {
+		return c, fmt.Errorf("sizeLimit: max must be > 0")
+	}
+	if c.Min < 0 || c.Min > c.Max {
+		return c, fmt.Errorf("sizeLimit: min must be between 0 and max (%d); is %d", c.Max, c.Min)
+	}
+	return c, nil
+}
+
+func (c SizeLimitChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	return w, r.WithContext(message.WithLimit(r.Context(), c.limit()))
+}
+
+func (c SizeLimitChaos) limit() int64 {
+	if c.Min <= 0 || c.Min >= c.Max {
+		return c.Max
+	}
+	return c.Min + Rand.Int63n(c.Max-c.Min+1)
+}
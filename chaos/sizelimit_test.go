@@ -0,0 +1,28 @@
+package chaos
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/segmentio/tracking-api-chaos/message"
+)
+
+func TestSizeLimit(t *testing.T) {
+	Seed(1)
+	c, err := decodeSizeLimit(map[interface{}]interface{}{"min": 100, "max": 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		_, req := c.Do(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/batch", nil))
+		if limit := message.LimitFor("batch", req); limit < 100 || limit > 200 {
+			t.Fatalf("expected limit within [100, 200]; got %d", limit)
+		}
+	}
+
+	for _, config := range []map[interface{}]interface{}{{}, {"min": 300, "max": 200}} {
+		if _, err := decodeSizeLimit(config); err == nil {
+			t.Errorf("%v: expected error", config)
+		}
+	}
+}
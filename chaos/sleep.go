@@ -0,0 +1,42 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrShutdown is returned by sleep when Shutdown is called.
+var ErrShutdown = errors.New("chaos: server shutting down")
+
+var (
+	shutdown     = make(chan struct{})
+	shutdownOnce sync.Once
+)
+
+// Shutdown cuts short any delay chaos is causing, so the server can shut
+// down without waiting on it.
+func Shutdown() {
+	shutdownOnce.Do(func() {
+		close(shutdown)
+	})
+}
+
+// sleep waits for d, or until ctx is done or Shutdown is called, in which
+// case it returns why.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-shutdown:
+		return ErrShutdown
+	}
+}
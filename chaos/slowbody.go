@@ -0,0 +1,59 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Read the request body at `BytesPerSecond`, `ChunkSize` bytes at a time, so
+// the client's writes back up and its write timeout fires.
+type SlowBodyChaos struct {
+	BytesPerSecond int64 `mapstructure:"bytesPerSecond"`
+	ChunkSize      int   `mapstructure:"chunkSize"`
+}
+
+func decodeSlowBody(v interface{}) (c SlowBodyChaos, err error) {
+	if err = decode(v, &c); err != nil {
+		return c, fmt.Errorf("invalid slowBody: %s", err)
+	}
+	if c.BytesPerSecond <= 0 {
+		return c, fmt.Errorf("slowBody: bytesPerSecond must be > 0; is %d", c.BytesPerSecond)
+	}
+	if c.ChunkSize < 0 {
+		return c, fmt.Errorf("slowBody: chunkSize must be >= 0; is %d", c.ChunkSize)
+	}
+	return c, nil
+}
+
+func (c SlowBodyChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	if c.BytesPerSecond <= 0 || r.Body == nil {
+		return w, r
+	}
+	r.Body = &throttledReader{ReadCloser: r.Body, ctx: r.Context(), chaos: c}
+	return w, r
+}
+
+type throttledReader struct {
+	io.ReadCloser
+	ctx   context.Context
+	chaos SlowBodyChaos
+}
+
+func (t *throttledReader) Read(p []byte) (n int, err error) {
+	if err = t.ctx.Err(); err != nil {
+		return
+	}
+	chunkSize := t.chaos.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 1
+	}
+	if len(p) > chunkSize {
+		p = p[:chunkSize]
+	}
+	n, err = t.ReadCloser.Read(p)
+	sleep(t.ctx, time.Duration(n)*time.Second/time.Duration(t.chaos.BytesPerSecond))
+	return
+}
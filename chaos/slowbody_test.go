@@ -0,0 +1,29 @@
+package chaos
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowBody(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/v1/batch", strings.NewReader(`{"userId":"user-id"}`))
+	_, req = SlowBodyChaos{BytesPerSecond: 100, ChunkSize: 5}.Do(rec, req)
+
+	start := time.Now()
+	b, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 20 bytes at 100 bytes/s
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("expected read to take at least 190ms; took %s", elapsed)
+	}
+	if string(b) != `{"userId":"user-id"}` {
+		t.Errorf("expected full body; got %q", b)
+	}
+}
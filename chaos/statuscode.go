@@ -0,0 +1,125 @@
+package chaos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/segmentio/events"
+	"github.com/segmentio/tracking-api-chaos/message"
+)
+
+// Write a specific HTTP status code, `Headers` and body. The body is `Body`,
+// or the contents of `BodyFile` (only allowed in the chaos config file, not
+// from the X-Chaos header or admin API), and may be a template (see
+// text/template) using the request's `.Path`, `.Method` and `.WriteKey`, and a
+// generated `.RequestID`. Values from the request are client controlled, so
+// quote them in JSON bodies with `json`, e.g.
+// `{"success":false,"writeKey":{{json .WriteKey}},"requestId":"{{.RequestID}}"}`.
+type StatusCodeChaos struct {
+	Code     int               `mapstructure:"code"`
+	Headers  map[string]string `mapstructure:"headers"`
+	Body     []byte            `mapstructure:"body"`
+	BodyFile string            `mapstructure:"bodyFile"`
+
+	template *template.Template
+}
+
+// The values a StatusCodeChaos body template can use.
+type statusCodeRequest struct {
+	Path      string
+	Method    string
+	WriteKey  string
+	RequestID string
+}
+
+// The functions a StatusCodeChaos body template can use.
+var templateFuncs = template.FuncMap{
+	// json quotes a value as JSON, e.g. a string from the request
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+func decodeStatusCode(v interface{}) (c StatusCodeChaos, err error) {
+	if err = decode(v, &c); err != nil {
+		return c, fmt.Errorf("invalid statusCode: %s", err)
+	}
+	if err = checkCode(StatusCode, c.Code); err != nil {
+		return c, err
+	}
+	if c.BodyFile != "" {
+		if c.Body != nil {
+			return c, fmt.Errorf("statusCode: set either body or bodyFile, not both")
+		}
+		c.Body, err = ioutil.ReadFile(c.BodyFile)
+		if err != nil {
+			return c, fmt.Errorf("statusCode: reading bodyFile: %s", err)
+		}
+	}
+	if strings.Contains(string(c.Body), "{{") {
+		c.template, err = template.New("body").Funcs(templateFuncs).Parse(string(c.Body))
+		if err != nil {
+			return c, fmt.Errorf("statusCode: invalid body template: %s", err)
+		}
+	}
+	return c, nil
+}
+
+// checkCode returns an error unless code is a status code net/http will write.
+func checkCode(kind Kind, code int) error {
+	if code < 100 || code > 599 {
+		return fmt.Errorf("%s: code must be between 100 and 599; is %d", kind, code)
+	}
+	return nil
+}
+
+// If Body is not nil, w will be replaced with a FakeResponseWriter
+func (c StatusCodeChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	for k, v := range c.Headers {
+		w.Header().Set(k, v)
+	}
+	w.WriteHeader(c.Code)
+	if c.Body == nil {
+		return w, r
+	}
+	w.Write(c.body(r))
+
+	return &FakeResponseWriter{}, r
+}
+
+// body returns Body, with the template, if any, filled in for r.
+func (c StatusCodeChaos) body(r *http.Request) []byte {
+	if c.template == nil {
+		return c.Body
+	}
+	var buf bytes.Buffer
+	err := c.template.Execute(&buf, statusCodeRequest{
+		Path:      r.URL.Path,
+		Method:    r.Method,
+		WriteKey:  message.WriteKey(r),
+		RequestID: requestID(),
+	})
+	if err != nil {
+		events.Log("statusCode: executing body template: %{error}s", err)
+		return c.Body
+	}
+	return buf.Bytes()
+}
+
+// requestID returns a random (version 4) UUID.
+func requestID() string {
+	// not Rand.Read, which isn't safe for concurrent use
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b[:8], Rand.Uint64())
+	binary.BigEndian.PutUint64(b[8:], Rand.Uint64())
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
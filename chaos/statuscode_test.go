@@ -0,0 +1,161 @@
+package chaos
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+func TestStatusCodeHeaders(t *testing.T) {
+	config, err := ParseConfig([]byte(`
+- statusCode:
+    code: 503
+    headers:
+      Retry-After: 30
+      Connection: close
+    body: '{"success":false}'
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	config.Chaos.Do(rec, httptest.NewRequest("POST", "/v1/track", nil))
+
+	if rec.Code != 503 {
+		t.Errorf("expected 503; got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "30" || rec.Header().Get("Connection") != "close" {
+		t.Errorf("expected configured headers; got %v", rec.Header())
+	}
+	if rec.Body.String() != `{"success":false}` {
+		t.Errorf("expected configured body; got %q", rec.Body.String())
+	}
+}
+
+func TestStatusCodeTemplate(t *testing.T) {
+	c, err := decodeStatusCode(map[interface{}]interface{}{
+		"code": 500,
+		"body": `{{.Method}} {{.Path}} {{.WriteKey}} {{.RequestID}}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/v1/track", nil)
+	req.SetBasicAuth("write-key", "")
+	c.Do(rec, req)
+
+	expected := regexp.MustCompile(`^POST /v1/track write-key [0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !expected.MatchString(rec.Body.String()) {
+		t.Errorf("expected templated body; got %q", rec.Body.String())
+	}
+}
+
+func TestStatusCodeTemplateJSON(t *testing.T) {
+	c, err := decodeStatusCode(map[interface{}]interface{}{
+		"code": 500,
+		"body": `{"writeKey":{{json .WriteKey}}}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/v1/track", nil)
+	req.SetBasicAuth(`key", "injected" "<x>`, "")
+	c.Do(rec, req)
+
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected valid JSON; got %q: %s", rec.Body.String(), err)
+	}
+	if len(body) != 1 || body["writeKey"] != `key", "injected" "<x>` {
+		t.Errorf("expected the write key quoted; got %q", rec.Body.String())
+	}
+}
+
+func TestStatusCodeBodyFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "body")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("<html><body>504 Gateway Time-out</body></html>")
+	f.Close()
+
+	c, err := decodeStatusCode(map[interface{}]interface{}{"code": 504, "bodyFile": f.Name()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	c.Do(rec, httptest.NewRequest("POST", "/v1/track", nil))
+	if rec.Body.String() != "<html><body>504 Gateway Time-out</body></html>" {
+		t.Errorf("expected body from file; got %q", rec.Body.String())
+	}
+
+	if _, err := decodeStatusCode(map[interface{}]interface{}{"bodyFile": f.Name() + ".missing"}); err == nil {
+		t.Error("expected error for missing bodyFile")
+	}
+	if _, err := decodeStatusCode(map[interface{}]interface{}{"body": "{{.Nope"}); err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
+
+func TestStatusCodeBodyFileUntrusted(t *testing.T) {
+	for _, value := range []string{
+		"statusCode; code=200; bodyFile=/etc/hostname",
+		"statusCode; code=200; BODYFILE=/etc/hostname",
+	} {
+		if _, err := ParseHeader(value); err == nil {
+			t.Errorf("%q: expected bodyFile to be rejected", value)
+		}
+	}
+
+	config := []byte(`
+- profiles:
+    default:
+      - statusCode:
+          code: 200
+          bodyFile: /etc/hostname
+`)
+	if _, err := ParseUntrustedConfig(config); err == nil {
+		t.Error("expected nested bodyFile to be rejected")
+	}
+	if _, err := ParseUntrustedConfig([]byte(DefaultConfigYAML)); err != nil {
+		t.Errorf("expected config without bodyFile to be accepted; got %s", err)
+	}
+}
+
+func TestRequestIDConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				requestID()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestStatusCodeInvalidCode(t *testing.T) {
+	for _, value := range []string{"statusCode", "statusCode=0", "statusCode=42", "statusCode=600", "malformed; code=1000"} {
+		if _, err := ParseHeader(value); err == nil {
+			t.Errorf("%q: expected invalid code to be rejected", value)
+		}
+	}
+	if _, err := ParseHeader("malformed"); err != nil {
+		t.Errorf("expected malformed without a code to keep the handler's; got %s", err)
+	}
+}
+
+func TestStatusCodeHeaderTypo(t *testing.T) {
+	if _, err := ParseHeader("statusCode=503; cod=200; boddy=x"); err == nil {
+		t.Error("expected unknown fields to be rejected")
+	}
+}
@@ -0,0 +1,73 @@
+package chaos
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Switch is a Chaos that forwards to another, which can be replaced while
+// requests are being served. An override can be pushed on top for a while,
+// after which the replaced chaos applies again.
+type Switch struct {
+	mu       sync.RWMutex
+	current  Chaos
+	override Chaos
+	expires  time.Time
+}
+
+func NewSwitch(c Chaos) *Switch {
+	return &Switch{current: c}
+}
+
+// Chaos returns the chaos in effect: the override if there is an unexpired
+// one, otherwise the current one.
+func (s *Switch) Chaos() Chaos {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.override != nil && time.Now().Before(s.expires) {
+		return s.override
+	}
+	return s.current
+}
+
+// Status returns the chaos in effect, as Chaos does, along with when it
+// expires if it's an override, or the zero time otherwise.
+func (s *Switch) Status() (Chaos, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.override != nil && time.Now().Before(s.expires) {
+		return s.override, s.expires
+	}
+	return s.current, time.Time{}
+}
+
+// Override returns the override and when it expires, or nil if there is none.
+func (s *Switch) Override() (Chaos, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.override != nil && time.Now().Before(s.expires) {
+		return s.override, s.expires
+	}
+	return nil, time.Time{}
+}
+
+// Set replaces the current chaos. Any override stays in effect until it
+// expires.
+func (s *Switch) Set(c Chaos) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = c
+}
+
+// SetOverride puts c in effect for ttl. A nil c clears the override.
+func (s *Switch) SetOverride(c Chaos, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.override = c
+	s.expires = time.Now().Add(ttl)
+}
+
+func (s *Switch) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	return s.Chaos().Do(w, r)
+}
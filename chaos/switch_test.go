@@ -0,0 +1,30 @@
+package chaos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSwitch(t *testing.T) {
+	current := NamedChaos("current")
+	override := NamedChaos("override")
+	s := NewSwitch(&current)
+
+	s.SetOverride(&override, 50*time.Millisecond)
+	if s.Chaos() != &override {
+		t.Error("expected override to be in effect")
+	}
+	if c, expires := s.Status(); c != &override || expires.IsZero() {
+		t.Error("expected status to be the override and when it expires")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if s.Chaos() != &current {
+		t.Error("expected override to expire")
+	}
+	if c, _ := s.Override(); c != nil {
+		t.Error("expected no override once expired")
+	}
+	if c, expires := s.Status(); c != &current || !expires.IsZero() {
+		t.Error("expected status to be the current chaos once the override expired")
+	}
+}
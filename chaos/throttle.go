@@ -0,0 +1,92 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Trickle the response out at `BytesPerSecond`, `ChunkSize` bytes at a time.
+// Headers are flushed straight away; the body is flushed every
+// `FlushInterval` ms, or after every chunk if that is 0.
+type ThrottleChaos struct {
+	BytesPerSecond int64 `mapstructure:"bytesPerSecond"`
+	ChunkSize      int   `mapstructure:"chunkSize"`
+	FlushInterval  int64 `mapstructure:"flushInterval"`
+}
+
+func decodeThrottle(v interface{}) (c ThrottleChaos, err error) {
+	if err = decode(v, &c); err != nil {
+		return c, fmt.Errorf("invalid throttle: %s", err)
+	}
+	if c.BytesPerSecond <= 0 {
+		return c, fmt.Errorf("throttle: bytesPerSecond must be > 0; is %d", c.BytesPerSecond)
+	}
+	if c.ChunkSize < 0 {
+		return c, fmt.Errorf("throttle: chunkSize must be >= 0; is %d", c.ChunkSize)
+	}
+	if c.FlushInterval < 0 {
+		return c, fmt.Errorf("throttle: flushInterval must be >= 0; is %d", c.FlushInterval)
+	}
+	return c, nil
+}
+
+func (c ThrottleChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	if c.BytesPerSecond <= 0 {
+		return w, r
+	}
+	return &throttledWriter{ResponseWriter: w, ctx: r.Context(), chaos: c}, r
+}
+
+type throttledWriter struct {
+	http.ResponseWriter
+	ctx     context.Context
+	chaos   ThrottleChaos
+	flushed time.Time
+}
+
+func (t *throttledWriter) WriteHeader(code int) {
+	t.ResponseWriter.WriteHeader(code)
+	t.flush()
+}
+
+func (t *throttledWriter) Write(b []byte) (n int, err error) {
+	chunkSize := t.chaos.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = 1
+	}
+	flushInterval := time.Duration(t.chaos.FlushInterval) * time.Millisecond
+
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > chunkSize {
+			chunk = chunk[:chunkSize]
+		}
+		var m int
+		m, err = t.ResponseWriter.Write(chunk)
+		n += m
+		if err != nil {
+			return
+		}
+		b = b[m:]
+		if time.Since(t.flushed) >= flushInterval {
+			t.flush()
+		}
+		// no point trickling bytes to a client that's gone, but on shutdown
+		// the rest is sent in one go
+		if err = sleep(t.ctx, time.Duration(m)*time.Second/time.Duration(t.chaos.BytesPerSecond)); err == ErrShutdown {
+			err = nil
+		} else if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (t *throttledWriter) flush() {
+	if flusher, ok := t.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	t.flushed = time.Now()
+}
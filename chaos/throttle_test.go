@@ -0,0 +1,29 @@
+package chaos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/v1/track", nil)
+	w, _ := ThrottleChaos{BytesPerSecond: 100, ChunkSize: 4}.Do(rec, req)
+
+	start := time.Now()
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"success":true}`))
+
+	// 16 bytes at 100 bytes/s
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("expected write to take at least 150ms; took %s", elapsed)
+	}
+	if rec.Body.String() != `{"success":true}` {
+		t.Errorf("expected full body; got %q", rec.Body.String())
+	}
+	if !rec.Flushed {
+		t.Error("expected response to be flushed")
+	}
+}
@@ -0,0 +1,99 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Send the real status and headers, with a Content-Length for the full body,
+// then only part of the body before closing the connection. `Bytes` is how
+// much of the body to send; `Fraction`, if set, overrides it as a share of the
+// body. `Pause` is how long to wait, in ms, before closing.
+type TruncateChaos struct {
+	Bytes    int     `mapstructure:"bytes"`
+	Fraction float64 `mapstructure:"fraction"`
+	Pause    int64   `mapstructure:"pause"`
+}
+
+func decodeTruncate(v interface{}) (c TruncateChaos, err error) {
+	if err = decode(v, &c); err != nil {
+		return c, fmt.Errorf("invalid truncate: %s", err)
+	}
+	if c.Bytes < 0 {
+		return c, fmt.Errorf("truncate: bytes must be >= 0; is %d", c.Bytes)
+	}
+	if c.Fraction < 0 || c.Fraction > 1 {
+		return c, fmt.Errorf("truncate: fraction must be between 0 and 1; is %f", c.Fraction)
+	}
+	if c.Pause < 0 {
+		return c, fmt.Errorf("truncate: pause must be >= 0; is %d", c.Pause)
+	}
+	return c, nil
+}
+
+func (c TruncateChaos) Do(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	return &truncatingWriter{ResponseWriter: w, ctx: r.Context(), chaos: c}, r
+}
+
+// keep returns how many bytes of an n byte body to send. At least one byte is
+// always cut, otherwise the response would just be a healthy one.
+func (c TruncateChaos) keep(n int) int {
+	keep := c.Bytes
+	if c.Fraction > 0 {
+		keep = int(float64(n) * c.Fraction)
+	}
+	if keep >= n {
+		keep = n - 1
+	}
+	if keep < 0 {
+		keep = 0
+	}
+	return keep
+}
+
+// truncatingWriter holds on to the status code until the downstream handler
+// writes its body, which it truncates. The Content-Length is taken from the
+// first Write, so handlers are expected to write their body in one go (as
+// `response.JSON` does).
+type truncatingWriter struct {
+	http.ResponseWriter
+	ctx   context.Context
+	chaos TruncateChaos
+	code  int
+	done  bool
+}
+
+func (t *truncatingWriter) WriteHeader(code int) {
+	if t.code == 0 {
+		t.code = code
+	}
+}
+
+func (t *truncatingWriter) Write(b []byte) (int, error) {
+	if t.done {
+		return len(b), nil
+	}
+	t.done = true
+	if t.code == 0 {
+		t.code = http.StatusOK
+	}
+
+	header := t.ResponseWriter.Header()
+	if header.Get("Content-Length") == "" {
+		header.Set("Content-Length", strconv.Itoa(len(b)))
+	}
+	t.ResponseWriter.WriteHeader(t.code)
+	t.ResponseWriter.Write(b[:t.chaos.keep(len(b))])
+	if flusher, ok := t.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	sleep(t.ctx, time.Duration(t.chaos.Pause)*time.Millisecond)
+	// a plain close rather than a reset, so the client gets what was sent
+	hijack(t.ResponseWriter).Close()
+
+	return len(b), nil
+}
@@ -0,0 +1,47 @@
+package chaos
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTruncate(t *testing.T) {
+	cases := []struct {
+		chaos TruncateChaos
+		body  string
+	}{
+		{TruncateChaos{}, ``},
+		{TruncateChaos{Bytes: 5}, `{"suc`},
+		{TruncateChaos{Fraction: 0.5}, `{"succes`},
+		{TruncateChaos{Bytes: 100}, `{"success":true`},
+	}
+
+	for _, tc := range cases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w, r = tc.chaos.Do(w, r)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"success":true}`))
+		}))
+
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("%#v: %s", tc.chaos, err)
+		}
+		if resp.ContentLength != 16 {
+			t.Errorf("%#v: expected Content-Length 16; got %d", tc.chaos, resp.ContentLength)
+		}
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("%#v: expected unexpected EOF; got %v", tc.chaos, err)
+		}
+		if string(b) != tc.body {
+			t.Errorf("%#v: expected body %q; got %q", tc.chaos, tc.body, b)
+		}
+		resp.Body.Close()
+		srv.Close()
+	}
+}
@@ -21,6 +21,12 @@ type Success struct {
 	Success bool `json:"success"`
 }
 
+// Failure response.
+type Failure struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
 // Routes.
 var Routes = map[string]string{
 	"/v1/i": "identify",
@@ -57,6 +63,16 @@ func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
 	typ := Routes[r.URL.Path]
 	msg, err := message.FromRequest(typ, w, r)
 
+	if errors.Cause(err) == message.ErrTooLarge {
+		// Close the connection rather than read the rest of a body.
+		w.Header().Set("Connection", "close")
+		response.RequestEntityTooLarge(w, &Failure{
+			Success: false,
+			Message: fmt.Sprintf("Payload too large, the limit is %d bytes", message.LimitFor(typ, r)),
+		})
+		return
+	}
+
 	if err != nil {
 		// Most errors are connections being dropped and the JSON decoder returning
 		// "unexpected EOF", this is not valuable information so we don't log it.
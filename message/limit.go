@@ -0,0 +1,118 @@
+package message
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ErrTooLarge is returned reading a request body over its size limit. It may be
+// wrapped with the limit and size; compare errors.Cause(err) to it.
+var ErrTooLarge = errors.New("[message] request too large")
+
+// Limits are the request size limits, in bytes. A limit for the request's
+// writeKey takes precedence over one for its route, which takes precedence
+// over the `Single` or `Batch` limit for its type.
+type Limits struct {
+	Single    int64            `yaml:"single"`
+	Batch     int64            `yaml:"batch"`
+	Routes    map[string]int64 `yaml:"routes"`
+	WriteKeys map[string]int64 `yaml:"writeKeys"`
+}
+
+// The limits in effect; see SetLimits.
+var limits = &Limits{Single: Single, Batch: Batch}
+
+// ParseLimits parses a YAML (or JSON) limits config. Unset type limits default
+// to Single and Batch; any other limit must be > 0.
+func ParseLimits(b []byte) (*Limits, error) {
+	l := &Limits{}
+	if err := yaml.Unmarshal(b, l); err != nil {
+		return nil, err
+	}
+	if l.Single < 0 || l.Batch < 0 {
+		return nil, fmt.Errorf("[message] single and batch limits must be > 0")
+	}
+	for route, n := range l.Routes {
+		if n <= 0 {
+			return nil, fmt.Errorf("[message] limit for route %s must be > 0; is %d", route, n)
+		}
+	}
+	for writeKey, n := range l.WriteKeys {
+		if n <= 0 {
+			return nil, fmt.Errorf("[message] limit for writeKey %s must be > 0; is %d", writeKey, n)
+		}
+	}
+	if l.Single == 0 {
+		l.Single = Single
+	}
+	if l.Batch == 0 {
+		l.Batch = Batch
+	}
+	return l, nil
+}
+
+// SetLimits replaces the limits in effect. It isn't safe to call while
+// requests are being served.
+func SetLimits(l *Limits) {
+	limits = l
+}
+
+type contextKey int
+
+const limitKey contextKey = iota
+
+// WithLimit lowers the size limit of requests with ctx to n bytes.
+func WithLimit(ctx context.Context, n int64) context.Context {
+	return context.WithValue(ctx, limitKey, n)
+}
+
+// LimitFor returns the size limit for r, a message `typ`.
+func LimitFor(typ string, r *http.Request) int64 {
+	limit, ok := limits.Routes[r.URL.Path]
+	if !ok {
+		limit = Limit(typ)
+	}
+	if len(limits.WriteKeys) > 0 {
+		if n, ok := limits.WriteKeys[WriteKey(r)]; ok {
+			limit = n
+		}
+	}
+	if n, ok := r.Context().Value(limitKey).(int64); ok && n < limit {
+		limit = n
+	}
+	return limit
+}
+
+// MaxBytesReader is like http.MaxBytesReader, but returns ErrTooLarge once
+// more than n bytes are read.
+func MaxBytesReader(r io.Reader, n int64) io.Reader {
+	return &maxBytesReader{r: r, n: n}
+}
+
+type maxBytesReader struct {
+	r io.Reader
+	n int64
+}
+
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.n < 0 {
+		return 0, ErrTooLarge
+	}
+	// read one byte more than allowed, to tell if there's too much
+	if int64(len(p)) > m.n+1 {
+		p = p[:m.n+1]
+	}
+	n, err := m.r.Read(p)
+	if int64(n) <= m.n {
+		m.n -= int64(n)
+		return n, err
+	}
+	n = int(m.n)
+	m.n = -1
+	return n, ErrTooLarge
+}
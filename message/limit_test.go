@@ -0,0 +1,99 @@
+package message
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestLimitFor(t *testing.T) {
+	l, err := ParseLimits([]byte(`
+batch: 1000
+routes:
+  /v1/import: 2000
+writeKeys:
+  small-key: 10
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetLimits(l)
+	defer SetLimits(&Limits{Single: Single, Batch: Batch})
+
+	tests := []struct {
+		typ      string
+		path     string
+		writeKey string
+		limit    int64
+	}{
+		{"track", "/v1/track", "", Single},
+		{"batch", "/v1/batch", "", 1000},
+		{"batch", "/v1/import", "", 2000},
+		{"batch", "/v1/import", "small-key", 10},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", test.path, strings.NewReader(`{}`))
+		if test.writeKey != "" {
+			req.SetBasicAuth(test.writeKey, "")
+		}
+		if limit := LimitFor(test.typ, req); limit != test.limit {
+			t.Errorf("%s %s %q: expected limit %d; got %d", test.typ, test.path, test.writeKey, test.limit, limit)
+		}
+	}
+
+	req := httptest.NewRequest("POST", "/v1/batch", nil)
+	if limit := LimitFor("batch", req.WithContext(WithLimit(req.Context(), 100))); limit != 100 {
+		t.Errorf("expected context to lower the limit to 100; got %d", limit)
+	}
+	if limit := LimitFor("batch", req.WithContext(WithLimit(req.Context(), 5000))); limit != 1000 {
+		t.Errorf("expected context not to raise the limit; got %d", limit)
+	}
+}
+
+func TestMaxBytesReader(t *testing.T) {
+	b, err := ioutil.ReadAll(MaxBytesReader(bytes.NewReader([]byte("0123456789")), 10))
+	if err != nil || string(b) != "0123456789" {
+		t.Errorf("expected body at the limit to be read; got %q, %v", b, err)
+	}
+	b, err = ioutil.ReadAll(MaxBytesReader(bytes.NewReader([]byte("0123456789")), 9))
+	if err != ErrTooLarge || string(b) != "012345678" {
+		t.Errorf("expected ErrTooLarge after the limit; got %q, %v", b, err)
+	}
+}
+
+func TestFromRequestTooLarge(t *testing.T) {
+	req := httptest.NewRequest("POST", "/v1/track", strings.NewReader(`{"event":"`+strings.Repeat("x", int(Single))+`"}`))
+	if _, err := FromRequest("track", httptest.NewRecorder(), req); err != ErrTooLarge {
+		t.Errorf("expected ErrTooLarge; got %v", err)
+	}
+}
+
+func TestFromBase64TooLarge(t *testing.T) {
+	data := base64.StdEncoding.EncodeToString([]byte(`{"event":"` + strings.Repeat("x", int(Single)) + `"}`))
+	req := httptest.NewRequest("GET", "/v1/t?data="+data, nil)
+	_, err := FromBase64("track", req)
+	if errors.Cause(err) != ErrTooLarge {
+		t.Fatalf("expected ErrTooLarge; got %v", err)
+	}
+	if !strings.Contains(err.Error(), fmt.Sprintf("limit=%d", Single)) {
+		t.Errorf("expected the limit in the error; got %v", err)
+	}
+}
+
+func TestParseLimitsInvalid(t *testing.T) {
+	for _, config := range []string{
+		"single: -1",
+		"routes:\n  /v1/import: 0",
+		"writeKeys:\n  small-key: -10",
+	} {
+		if _, err := ParseLimits([]byte(config)); err == nil {
+			t.Errorf("expected an error parsing %q", config)
+		}
+	}
+}
@@ -1,37 +1,41 @@
 package message
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+
+	"github.com/pkg/errors"
 )
 
-// Single message is limited to 32KB.
+// Single message is limited to 32KB by default.
 const Single int64 = 32 << 10
 
-// Batch message is limited to 500KB.
+// Batch message is limited to 500KB by default.
 const Batch int64 = 500 << 10
 
 // FromRequest reads a message `typ` from the given `request`.
 func FromRequest(typ string, w http.ResponseWriter, r *http.Request) (*Message, error) {
-	limit := Limit(typ)
-
 	if "GET" == r.Method {
 		return FromBase64(typ, r)
 	}
 
+	limit := LimitFor(typ, r)
+
 	var body RawBody
 	msg := New(r)
-	req := http.MaxBytesReader(w, r.Body, limit)
+	req := MaxBytesReader(r.Body, limit)
 	dec := json.NewDecoder(req)
 	err := dec.Decode(&body)
 
 	switch err {
 	case nil:
-	case io.ErrUnexpectedEOF:
+	case io.ErrUnexpectedEOF, ErrTooLarge:
 		return nil, err
 	default:
 		return nil, fmt.Errorf("[message] error decoding json from request: %v", err)
@@ -43,7 +47,7 @@ func FromRequest(typ string, w http.ResponseWriter, r *http.Request) (*Message,
 
 // FromBase64 decodes data from `?data` query string.
 func FromBase64(typ string, r *http.Request) (*Message, error) {
-	limit := Limit(typ)
+	limit := LimitFor(typ, r)
 	data := r.URL.Query().Get("data")
 
 	buf, err := decodeBase64(data)
@@ -52,7 +56,7 @@ func FromBase64(typ string, r *http.Request) (*Message, error) {
 	}
 
 	if limit < int64(len(buf)) {
-		return nil, fmt.Errorf("[message] request too large (limit=%v size=%v)", limit, len(buf))
+		return nil, errors.Wrapf(ErrTooLarge, "limit=%v size=%v", limit, len(buf))
 	}
 
 	var body RawBody
@@ -115,6 +119,99 @@ func FromQuery(typ string, r *http.Request) (*Message, error) {
 	return msg, nil
 }
 
+// WriteKey returns the writeKey `r` was sent with: the Basic auth username or,
+// failing that, the `writeKey` field (see Field).
+func WriteKey(r *http.Request) string {
+	if user, _, ok := r.BasicAuth(); ok && user != "" {
+		return user
+	}
+	return Field(r, "writeKey")
+}
+
+// Field returns the top level field `name` of the message in `r`, looking in
+// turn at the query param `name`, the base64 `data` param and the (possibly
+// gzipped) JSON body. Strings are returned as is and anything else as JSON.
+// The body is only read as far as the field, which is put back in front of the
+// rest for the handler.
+func Field(r *http.Request, name string) string {
+	query := r.URL.Query()
+	if value := query.Get(name); value != "" {
+		return value
+	}
+
+	var body map[string]json.RawMessage
+
+	if data := query.Get("data"); data != "" {
+		if buf, err := decodeBase64(data); err == nil {
+			json.Unmarshal(buf, &body)
+		}
+		return fieldString(body[name])
+	}
+
+	if r.Body == nil || "GET" == r.Method {
+		return ""
+	}
+
+	// Only read as far as the field, so that the rest of the body is still
+	// sent at whatever pace the client and any chaos make it.
+	reqBody := r.Body
+	var peeked bytes.Buffer
+	defer func() {
+		r.Body = &peekedBody{
+			Reader: io.MultiReader(&peeked, reqBody),
+			Closer: reqBody,
+		}
+	}()
+
+	var dec io.Reader = io.TeeReader(io.LimitReader(reqBody, Batch), &peeked)
+	if strings.TrimSpace(r.Header.Get("Content-Encoding")) == "gzip" {
+		z, err := gzip.NewReader(dec)
+		if err != nil {
+			return ""
+		}
+		defer z.Close()
+		dec = io.LimitReader(z, Batch)
+	}
+
+	return fieldString(scanField(json.NewDecoder(dec), name))
+}
+
+// scanField returns the top level field `name` of the JSON object in dec,
+// reading no further than its value.
+func scanField(dec *json.Decoder, name string) json.RawMessage {
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil
+		}
+		if key, _ := tok.(string); key == name {
+			return value
+		}
+	}
+	return nil
+}
+
+func fieldString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// peekedBody puts what was read of a request body back in front of the rest.
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
 var base64Decoders = [...](func(string) ([]byte, error)){
 	base64.RawURLEncoding.DecodeString,
 	base64.URLEncoding.DecodeString,
@@ -132,13 +229,14 @@ func decodeBase64(data string) (b []byte, err error) {
 	return
 }
 
-// Limit returns size limit for message `typ`.
+// Limit returns the configured size limit for message `typ`; see LimitFor
+// for a given request's limit.
 func Limit(typ string) int64 {
 	switch typ {
 	case "batch":
-		return Batch
+		return limits.Batch
 	default:
-		return Single
+		return limits.Single
 	}
 }
 
@@ -1,6 +1,15 @@
 package message
 
-import "testing"
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
 
 func TestDecodeBase64(t *testing.T) {
 	tests := []struct {
@@ -25,3 +34,113 @@ func TestDecodeBase64(t *testing.T) {
 		}
 	}
 }
+
+func TestWriteKey(t *testing.T) {
+	gzipped := new(bytes.Buffer)
+	z := gzip.NewWriter(gzipped)
+	z.Write([]byte(`{"writeKey":"gzip-key"}`))
+	z.Close()
+
+	tests := []struct {
+		name     string
+		req      *http.Request
+		writeKey string
+		body     string
+	}{
+		{
+			name:     "basicAuth",
+			req:      withBasicAuth(httptest.NewRequest("POST", "/v1/track", strings.NewReader(`{"writeKey":"body-key"}`)), "auth-key"),
+			writeKey: "auth-key",
+			body:     `{"writeKey":"body-key"}`,
+		},
+		{
+			name:     "query",
+			req:      httptest.NewRequest("GET", "/v1/pixel/track?writeKey=query-key", nil),
+			writeKey: "query-key",
+		},
+		{
+			name:     "base64",
+			req:      httptest.NewRequest("GET", "/v1/pixel/track?data=eyJ3cml0ZUtleSI6ImFzZGYifQ", nil),
+			writeKey: "asdf",
+		},
+		{
+			name:     "body",
+			req:      httptest.NewRequest("POST", "/v1/track", strings.NewReader(`{"writeKey":"body-key"}`)),
+			writeKey: "body-key",
+			body:     `{"writeKey":"body-key"}`,
+		},
+		{
+			name: "gzip",
+			req: func() *http.Request {
+				r := httptest.NewRequest("POST", "/v1/track", bytes.NewReader(gzipped.Bytes()))
+				r.Header.Set("Content-Encoding", "gzip")
+				return r
+			}(),
+			writeKey: "gzip-key",
+			body:     gzipped.String(),
+		},
+		{
+			name: "none",
+			req:  httptest.NewRequest("POST", "/v1/track", strings.NewReader(`{"userId":"user-id"}`)),
+			body: `{"userId":"user-id"}`,
+		},
+	}
+
+	for _, test := range tests {
+		if writeKey := WriteKey(test.req); writeKey != test.writeKey {
+			t.Errorf("%s: expected writeKey %q; got %q", test.name, test.writeKey, writeKey)
+		}
+		if test.body == "" {
+			continue
+		}
+		if b, _ := ioutil.ReadAll(test.req.Body); string(b) != test.body {
+			t.Errorf("%s: body not left in place; got %q", test.name, b)
+		}
+	}
+}
+
+func withBasicAuth(r *http.Request, user string) *http.Request {
+	r.SetBasicAuth(user, "")
+	return r
+}
+
+func TestField(t *testing.T) {
+	r := httptest.NewRequest("POST", "/v1/track", strings.NewReader(`{"messageId":"message-id","timestamp":1}`))
+	if messageId := Field(r, "messageId"); messageId != "message-id" {
+		t.Errorf("expected messageId %q; got %q", "message-id", messageId)
+	}
+	if timestamp := Field(r, "timestamp"); timestamp != "1" {
+		t.Errorf("expected timestamp %q; got %q", "1", timestamp)
+	}
+	if missing := Field(r, "missing"); missing != "" {
+		t.Errorf("expected missing field to be empty; got %q", missing)
+	}
+}
+
+// countingReader counts how much of a body has been read.
+type countingReader struct {
+	io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestFieldReadsOnlyToField(t *testing.T) {
+	body := `{"writeKey":"write-key","batch":["` + strings.Repeat("x", 200<<10) + `"]}`
+	counter := &countingReader{Reader: strings.NewReader(body)}
+	r := httptest.NewRequest("POST", "/v1/batch", counter)
+
+	if writeKey := Field(r, "writeKey"); writeKey != "write-key" {
+		t.Errorf("expected writeKey %q; got %q", "write-key", writeKey)
+	}
+	if counter.n > 64<<10 {
+		t.Errorf("expected the body to be read only as far as writeKey; read %d bytes", counter.n)
+	}
+	if b, _ := ioutil.ReadAll(r.Body); string(b) != body {
+		t.Errorf("expected the body to be left in place; got %d bytes", len(b))
+	}
+}
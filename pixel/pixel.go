@@ -53,6 +53,8 @@ func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
 		msg, err = message.FromQuery(typ, r)
 	}
 
+	// Unlike the other routes, data over the size limit still gets the GIF, as
+	// browsers loading a pixel ignore its status anyway.
 	if err != nil {
 		events.Log("[pixel]: %{error}s", errors.Wrap(err, "reading pixel data"))
 	} else {
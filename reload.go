@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/segmentio/events"
+	"github.com/segmentio/tracking-api-chaos/chaos"
+)
+
+// watchChaos reloads the chaos config at path into s on each signal from hup
+// and, if interval is non-zero, whenever polling it every interval finds it
+// changed. If there's no file to reload (path is "" or "-"), signals are
+// only logged, so they don't kill the server.
+func watchChaos(path string, interval time.Duration, hup <-chan os.Signal, s *chaos.Switch) {
+	if path == "" || path == "-" {
+		for range hup {
+			events.Log("received SIGHUP, but the chaos config isn't from a file; nothing to reload")
+		}
+		return
+	}
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	last := modTime(path)
+	for {
+		select {
+		case <-hup:
+			events.Log("received SIGHUP, reloading chaos config %{chaosConfig}s", path)
+			last = modTime(path)
+			reloadChaos(path, s)
+		case <-tick:
+			if t := modTime(path); !t.Equal(last) {
+				last = t
+				events.Log("chaos config %{chaosConfig}s changed, reloading", path)
+				reloadChaos(path, s)
+			}
+		}
+	}
+}
+
+// reloadChaos reads the chaos config at path into s. If it can't be read or
+// isn't valid, the error is logged and the chaos already in effect is kept.
+func reloadChaos(path string, s *chaos.Switch) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		events.Log("reading chaos config %{chaosConfig}s failed, keeping the old one: %{error}s", path, err)
+		return
+	}
+	c, err := chaos.ParseConfig(b)
+	if err != nil {
+		events.Log("unmarshaling chaos config %{chaosConfig}s failed, keeping the old one: %{error}s", path, err)
+		return
+	}
+	s.Set(c)
+	events.Log("reloaded chaos config %{chaosConfig}s", path)
+	events.Debug("chaosRoot: %#v", c)
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
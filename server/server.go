@@ -3,6 +3,7 @@ package server
 import (
 	"bytes"
 	"compress/gzip"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -29,10 +30,6 @@ var options = cors.Options{
 // Channel.
 const channel = "server"
 
-// 500kb Limit that should apply to all routes. Same as the batch limit as that
-// is our biggest limit.
-const limit int64 = 500 << 10
-
 // Response
 type Response struct {
 	Success bool   `json:"success"`
@@ -74,6 +71,8 @@ func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	typ := Routes[r.URL.Path]
 	encoding := strings.TrimSpace(r.Header.Get("Content-Encoding"))
+	// Before the body is decompressed, so a writeKey in it can be found.
+	limit := message.LimitFor(typ, r)
 
 	if encoding == "gzip" {
 		z, err := gzip.NewReader(r.Body)
@@ -92,8 +91,12 @@ func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
 
 	// Read the body now since if the request errors, we can't read it after
 	// `message.FromRequest`. Limit the reader so we don't try to read too much.
-	limitReader := http.MaxBytesReader(w, r.Body, limit)
+	limitReader := message.MaxBytesReader(r.Body, limit)
 	b, err := ioutil.ReadAll(limitReader)
+	if err == message.ErrTooLarge {
+		tooLarge(w, limit)
+		return
+	}
 	if err != nil {
 		response.BadRequest(w)
 		return
@@ -126,3 +129,13 @@ func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
 		Success: true,
 	})
 }
+
+// tooLarge responds with a 413, closing the connection rather than reading the
+// rest of the body.
+func tooLarge(w http.ResponseWriter, limit int64) {
+	w.Header().Set("Connection", "close")
+	response.RequestEntityTooLarge(w, &Response{
+		Success: false,
+		Message: fmt.Sprintf("Payload too large, the limit is %d bytes", limit),
+	})
+}
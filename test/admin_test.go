@@ -0,0 +1,101 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/segmentio/tracking-api-chaos/chaos"
+)
+
+func TestAdmin(t *testing.T) {
+	srv := NewChaosServerTest(chaos.NopChaos{})
+	srv.AllowAdmin(true)
+	track := func() *http.Request { return post("/v1/track", `{"userId": "user-id", "event": "event"}`) }
+	jsonGet := func() *http.Request {
+		req, err := http.NewRequest("GET", "http://api.test/internal/chaos", nil)
+		check(err)
+		req.Header.Set("Accept", "application/json")
+		return req
+	}
+
+	cases := []TTData{
+		{
+			name:    "healthy",
+			reqFunc: track,
+			code:    http.StatusOK,
+		},
+		{
+			name:     "replace",
+			req:      put("/internal/chaos", "- statusCode:\n    code: 503\n"),
+			code:     http.StatusOK,
+			bodyResp: `{"success":true}`,
+		},
+		{
+			name:     "get",
+			reqFunc:  jsonGet,
+			code:     http.StatusOK,
+			bodyResp: `{"chaos":[{"statusCode":{"code":503}}],"override":false}` + "\n",
+		},
+		{
+			name:    "replaced",
+			reqFunc: track,
+			code:    http.StatusServiceUnavailable,
+		},
+		{
+			name: "override",
+			req:  post("/internal/chaos/override?ttl=1m", `[]`),
+			code: http.StatusOK,
+		},
+		{
+			name:    "overridden",
+			reqFunc: track,
+			code:    http.StatusOK,
+		},
+		{
+			name: "clearOverride",
+			req: func() *http.Request {
+				req, err := http.NewRequest("DELETE", "http://api.test/internal/chaos/override", nil)
+				check(err)
+				return req
+			}(),
+			code: http.StatusOK,
+		},
+		{
+			name:    "overrideCleared",
+			reqFunc: track,
+			code:    http.StatusServiceUnavailable,
+		},
+		{
+			name: "overrideWithoutTTL",
+			req:  post("/internal/chaos/override", `[]`),
+			code: http.StatusBadRequest,
+		},
+		{
+			name: "invalid",
+			req:  put("/internal/chaos", "- nope: {}\n"),
+			code: http.StatusBadRequest,
+		},
+		{
+			name:    "invalidIgnored",
+			reqFunc: track,
+			code:    http.StatusServiceUnavailable,
+		},
+	}
+	for _, tc := range cases {
+		srv.runTestCase(t, tc)
+	}
+}
+
+func TestAdminDisabled(t *testing.T) {
+	srv := NewChaosServerTest(chaos.NopChaos{})
+	srv.runTestCase(t, TTData{
+		name: "replaceDisabled",
+		req:  put("/internal/chaos", "- statusCode:\n    code: 503\n"),
+		code: http.StatusNotFound,
+	})
+	srv.runTestCase(t, TTData{
+		name: "notReplaced",
+		req:  post("/v1/track", `{"userId": "user-id", "event": "event"}`),
+		code: http.StatusOK,
+	})
+}
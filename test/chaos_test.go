@@ -0,0 +1,166 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bmizerany/assert"
+	"github.com/segmentio/tracking-api-chaos/chaos"
+	"github.com/segmentio/tracking-api-chaos/message"
+	"github.com/segmentio/tracking-api-chaos/tracker"
+)
+
+func TestDropChaos(t *testing.T) {
+	srv := NewChaosServerTest(chaos.DropChaos{})
+	srv.runTestCase(t, TTData{
+		name:     "dropTrack",
+		req:      post("/v1/track", `{"userId": "user-id", "event": "event"}`),
+		code:     http.StatusOK,
+		bodyResp: `{"success":true}`,
+	})
+	assert.Equal(t, "", srv.outbuf.String())
+	assert.Equal(t, `{"body":{"event":"event","receivedAt":"0001-01-01T00:00:00Z","userId":"user-id"},"method":"POST","path":"/v1/track","headers":{}}`+"\n", srv.droppedbuf.String())
+}
+
+func TestDuplicateChaos(t *testing.T) {
+	srv := NewChaosServerTest(chaos.DuplicateChaos{Copies: 3})
+	srv.runTestCase(t, TTData{
+		name:     "duplicateTrack",
+		req:      post("/v1/track", `{"userId": "user-id", "event": "event"}`),
+		code:     http.StatusOK,
+		bodyResp: `{"success":true}`,
+	})
+	msg := `{"body":{"event":"event","receivedAt":"0001-01-01T00:00:00Z","userId":"user-id"},"method":"POST","path":"/v1/track","headers":{}}` + "\n"
+	assert.Equal(t, msg+msg+msg, srv.outbuf.String())
+}
+
+func TestDuplicateDone(t *testing.T) {
+	var out bytes.Buffer
+	done := make(chan struct{})
+	ctx := tracker.WithDuplicates(context.Background(), 3, 10*time.Millisecond, done)
+	tracker.New(&out, ioutil.Discard).Publish(ctx, &message.Message{Path: "/v1/track", Body: message.Body{}})
+	close(done)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, strings.Count(out.String(), "\n"))
+}
+
+func TestAckFailureChaos(t *testing.T) {
+	srv := NewChaosServerTest(chaos.AckFailureChaos{Code: http.StatusInternalServerError, Body: []byte("Something went wrong")})
+	srv.runTestCase(t, TTData{
+		name:     "ackFailureTrack",
+		req:      post("/v1/track", `{"userId": "user-id", "event": "event"}`),
+		code:     http.StatusInternalServerError,
+		bodyResp: `Something went wrong`,
+	})
+	assert.Equal(t, `{"body":{"event":"event","receivedAt":"0001-01-01T00:00:00Z","userId":"user-id"},"method":"POST","path":"/v1/track","headers":{}}`+"\n", srv.outbuf.String())
+}
+
+func TestMalformedChaos(t *testing.T) {
+	srv := NewChaosServerTest(chaos.MalformedChaos{Mode: chaos.MalformedInvalid})
+	srv.runTestCase(t, TTData{
+		name:     "malformedPixel",
+		req:      get("/v1/pixel/track", `{"userId": "user-id", "event": "event"}`),
+		code:     http.StatusOK,
+		bodyResp: "not an image",
+		headers:  http.Header{"Content-Type": []string{"image/gif"}},
+	})
+	assert.Equal(t, `{"body":{"event":"event","receivedAt":"0001-01-01T00:00:00Z","userId":"user-id"},"method":"GET","path":"/v1/pixel/track","headers":{}}`+"\n", srv.outbuf.String())
+}
+
+func TestRedirectChaos(t *testing.T) {
+	srv := NewChaosServerTest(chaos.RedirectChaos{Code: http.StatusTemporaryRedirect, MaxHops: 2})
+	srv.runTestCase(t, TTData{
+		name:    "redirectTrack",
+		req:     post("/v1/track?chaosRedirectHops=2", `{"userId": "user-id", "event": "event"}`),
+		code:    http.StatusTemporaryRedirect,
+		headers: http.Header{"Location": []string{"http://api.test/v1/track?chaosRedirectHops=3"}},
+	})
+	srv.runTestCase(t, TTData{
+		name: "redirectLoop",
+		req:  post("/v1/track?chaosRedirectHops=3", `{"userId": "user-id", "event": "event"}`),
+		code: http.StatusLoopDetected,
+	})
+	assert.Equal(t, "", srv.outbuf.String())
+
+	// once the client is through, the hop count isn't recorded
+	srv = NewServerTest()
+	srv.runTestCase(t, TTData{
+		name:   "redirectFollowed",
+		req:    query("/v1/pixel/track", "userId=user-id&event=event&chaosRedirectHops=1"),
+		code:   http.StatusOK,
+		outMsg: `{"body":{"event":"event","receivedAt":"0001-01-01T00:00:00Z","userId":"user-id"},"method":"GET","path":"/v1/pixel/track","headers":{}}`,
+	})
+}
+
+func TestSizeLimitChaos(t *testing.T) {
+	srv := NewChaosServerTest(chaos.SizeLimitChaos{Max: 32})
+	srv.runTestCase(t, TTData{
+		name:     "sizeLimitBatch",
+		req:      post("/v1/batch", `{"batch": [{"userId": "user-id", "event": "event"}]}`),
+		code:     http.StatusRequestEntityTooLarge,
+		bodyResp: `{"success":false,"message":"Payload too large, the limit is 32 bytes"}`,
+		headers:  http.Header{"Connection": []string{"close"}},
+	})
+	srv.runTestCase(t, TTData{
+		name:     "sizeLimitTrack",
+		req:      post("/v1/track", `{"event": "event"}`),
+		code:     http.StatusOK,
+		bodyResp: `{"success":true}`,
+	})
+}
+
+func TestHeaderChaos(t *testing.T) {
+	withHeader := func(value string) func() *http.Request {
+		return func() *http.Request {
+			req := post("/v1/track", `{"userId": "user-id", "event": "event"}`)
+			req.Header.Set("X-Chaos", value)
+			return req
+		}
+	}
+	outMsg := `{"body":{"event":"event","receivedAt":"0001-01-01T00:00:00Z","userId":"user-id"},"method":"POST","path":"/v1/track","headers":{}}`
+
+	srv := NewServerTest()
+	srv.AllowChaosHeader(true)
+	cases := []TTData{
+		{
+			name:    "statusCode",
+			reqFunc: withHeader("statusCode=503"),
+			code:    http.StatusServiceUnavailable,
+		},
+		{
+			name:     "fields",
+			reqFunc:  withHeader("statusCode; code=500; body=oops"),
+			code:     http.StatusInternalServerError,
+			bodyResp: "oops",
+		},
+		{
+			name:    "headerNotRecorded",
+			reqFunc: withHeader("latency=1"),
+			code:    http.StatusOK,
+			outMsg:  outMsg,
+		},
+		{
+			name:    "invalidIgnored",
+			reqFunc: withHeader("nope=1"),
+			code:    http.StatusOK,
+			outMsg:  outMsg,
+		},
+	}
+	for _, tc := range cases {
+		srv.runTestCase(t, tc)
+	}
+
+	srv = NewServerTest()
+	srv.runTestCase(t, TTData{
+		name:    "disallowed",
+		reqFunc: withHeader("statusCode=503"),
+		code:    http.StatusOK,
+		outMsg:  outMsg,
+	})
+}
@@ -1,9 +1,12 @@
 package test
 
 import (
+	"strings"
 	"testing"
 
 	"net/http"
+
+	"github.com/segmentio/tracking-api-chaos/message"
 )
 
 func TestJSON(t *testing.T) {
@@ -85,3 +88,27 @@ func TestJSON(t *testing.T) {
 		srv.runTestCase(t, tc)
 	}
 }
+
+func TestClientLargePayloads(t *testing.T) {
+	huge := `{"event":"` + strings.Repeat("x", int(message.Single)) + `"}`
+	cases := []TTData{
+		{
+			name:     "clientLargeJSON",
+			req:      post("/v1/t", huge),
+			code:     http.StatusRequestEntityTooLarge,
+			bodyResp: `{"success":false,"message":"Payload too large, the limit is 32768 bytes"}`,
+			headers:  http.Header{"Connection": {"close"}},
+		},
+		{
+			name:     "clientLargeBase64",
+			req:      get("/v1/t", huge),
+			code:     http.StatusRequestEntityTooLarge,
+			bodyResp: `{"success":false,"message":"Payload too large, the limit is 32768 bytes"}`,
+		},
+	}
+
+	for _, tc := range cases {
+		srv := NewServerTest()
+		srv.runTestCase(t, tc)
+	}
+}
@@ -26,8 +26,9 @@ import (
 // ServerTest assumes that 1 valid request will result in
 // exactly 1 `out` message.Message.
 type ServerTest struct {
-	outbuf  *bytes.Buffer
-	timeout time.Duration
+	outbuf     *bytes.Buffer
+	droppedbuf *bytes.Buffer
+	timeout    time.Duration
 	*api.Server
 }
 
@@ -51,12 +52,17 @@ type TTData struct {
 }
 
 func NewServerTest() *ServerTest {
-	var outbuf bytes.Buffer
+	return NewChaosServerTest(chaos.NopChaos{})
+}
+
+func NewChaosServerTest(chaosRoot chaos.Chaos) *ServerTest {
+	var outbuf, droppedbuf bytes.Buffer
 
 	return &ServerTest{
-		outbuf:  &outbuf,
-		Server:  api.New(&outbuf, chaos.NopChaos{}),
-		timeout: 1 * time.Second,
+		outbuf:     &outbuf,
+		droppedbuf: &droppedbuf,
+		Server:     api.New(&outbuf, &droppedbuf, chaosRoot),
+		timeout:    1 * time.Second,
 	}
 }
 
@@ -349,8 +355,8 @@ func TestServerLargePayloads(t *testing.T) {
 				req := post("/v1/identify", string(buf))
 				return req
 			},
-			code:     http.StatusBadRequest,
-			bodyResp: "Bad Request\n",
+			code:     http.StatusRequestEntityTooLarge,
+			bodyResp: `{"success":false,"message":"Payload too large, the limit is 32768 bytes"}`,
 		},
 		{
 			name: "largeJsonBatch",
@@ -362,8 +368,8 @@ func TestServerLargePayloads(t *testing.T) {
 				req := post("/v1/batch", string(buf))
 				return req
 			},
-			code:     http.StatusBadRequest,
-			bodyResp: "Bad Request\n",
+			code:     http.StatusRequestEntityTooLarge,
+			bodyResp: `{"success":false,"message":"Payload too large, the limit is 512000 bytes"}`,
 		},
 	}
 
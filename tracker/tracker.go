@@ -3,6 +3,7 @@ package tracker
 import (
 	"encoding/json"
 	"io"
+	"io/ioutil"
 	"sync"
 	"time"
 
@@ -17,34 +18,120 @@ var Now = func() time.Time {
 	return time.Now().UTC()
 }
 
+type contextKey int
+
+const (
+	dropKey contextKey = iota
+	duplicateKey
+)
+
+// WithDrop returns a copy of ctx for which Publish writes messages to the
+// dropped stream instead of out, while still reporting success.
+func WithDrop(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dropKey, true)
+}
+
+func dropped(ctx context.Context) bool {
+	drop, _ := ctx.Value(dropKey).(bool)
+	return drop
+}
+
+type duplicates struct {
+	copies int
+	delay  time.Duration
+	done   <-chan struct{}
+}
+
+// WithDuplicates returns a copy of ctx for which Publish writes messages to
+// out `copies` times, `delay` apart. When delay is non-zero, copies after the
+// first are written in the background so the response isn't held up; once
+// done is closed, any copies still waiting are dropped.
+func WithDuplicates(ctx context.Context, copies int, delay time.Duration, done <-chan struct{}) context.Context {
+	return context.WithValue(ctx, duplicateKey, duplicates{copies: copies, delay: delay, done: done})
+}
+
 type Tracker struct {
-	out     io.Writer
-	outJson *json.Encoder
+	out         io.Writer
+	outJson     *json.Encoder
+	dropped     io.Writer
+	droppedJson *json.Encoder
 
 	// We lock our output files to ensure no overlapping writes
 	// TODO: is this necessary? leaning yes
 	outLock sync.Mutex
 }
 
-// New returns a new tracker.
-func New(out io.Writer) *Tracker {
+// New returns a new tracker. Messages dropped by chaos are written to
+// dropped, which may be nil.
+func New(out io.Writer, dropped io.Writer) *Tracker {
+	if dropped == nil {
+		dropped = ioutil.Discard
+	}
 	return &Tracker{
-		out:     out,
-		outJson: json.NewEncoder(out),
+		out:         out,
+		outJson:     json.NewEncoder(out),
+		dropped:     dropped,
+		droppedJson: json.NewEncoder(dropped),
 	}
 }
 
-// Writes a msg to s.outJson, followed by a newline
+// Writes a msg to s.outJson, followed by a newline. If ctx came from
+// WithDrop, msg goes to s.droppedJson instead; if it came from
+// WithDuplicates, msg is written more than once.
 func (t *Tracker) Publish(ctx context.Context, msg *message.Message) (err error) {
 	if err = msg.Body.SetReceivedAt(Now()); err != nil {
 		events.Log("[tracker]: %{error}s", errors.Wrap(err, "setting received time"))
 		return
 	}
 
+	if dropped(ctx) {
+		events.Debug("[tracker]: dropping message for %{path}s", msg.Path)
+		t.outLock.Lock()
+		defer t.outLock.Unlock()
+		if err := t.droppedJson.Encode(msg); err != nil {
+			events.Log("[tracker]: %{error}s", errors.Wrap(err, "marshaling dropped JSON"))
+		}
+		return nil
+	}
+
+	if err = t.write(msg); err != nil {
+		return
+	}
+
+	dup, _ := ctx.Value(duplicateKey).(duplicates)
+	if dup.copies <= 1 {
+		return
+	}
+	events.Debug("[tracker]: duplicating message for %{path}s %{copies}d times", msg.Path, dup.copies)
+	if dup.delay == 0 {
+		for i := 1; i < dup.copies && err == nil; i++ {
+			err = t.write(msg)
+		}
+		return
+	}
+	go func() {
+		timer := time.NewTimer(dup.delay)
+		defer timer.Stop()
+		for i := 1; i < dup.copies; i++ {
+			select {
+			case <-timer.C:
+			case <-dup.done:
+				events.Debug("[tracker]: dropping %{copies}d pending copies of message for %{path}s", dup.copies-i, msg.Path)
+				return
+			}
+			t.write(msg)
+			timer.Reset(dup.delay)
+		}
+	}()
+	return
+}
+
+func (t *Tracker) write(msg *message.Message) (err error) {
 	t.outLock.Lock()
 	defer t.outLock.Unlock()
 
-	err = t.outJson.Encode(msg)
-	events.Log("[tracker]: %{error}s", errors.Wrap(err, "marshaling JSON"))
+	if err = t.outJson.Encode(msg); err != nil {
+		events.Log("[tracker]: %{error}s", errors.Wrap(err, "marshaling JSON"))
+	}
 	return
 }
@@ -20,14 +20,20 @@ import (
 	_ "github.com/segmentio/events/text"
 	"github.com/segmentio/tracking-api-chaos/api"
 	"github.com/segmentio/tracking-api-chaos/chaos"
-	yaml "gopkg.in/yaml.v2"
+	"github.com/segmentio/tracking-api-chaos/message"
 )
 
 type config struct {
 	Bind            string        `conf:"bind" help:"Address on which tracking-api listens for incoming connections (default: ':8080')"`
 	Debug           bool          `conf:"debug" help:"Turn on debug mode."`
 	Out             string        `conf:"out" help:"file to write tracking events to (see message/message.go:Message) (default: /dev/null)"`
-	ChaosConfig     string        `conf:"chaos" help:"file to load chaos config from ('-': stdin; default: see README.md for example)"`
+	Dropped         string        `conf:"dropped" help:"file to write tracking events dropped by chaos to (default: /dev/null)"`
+	ChaosConfig     string        `conf:"chaos" help:"file to load chaos config from ('-': stdin; default: see README.md for example); reloaded on SIGHUP"`
+	ChaosWatch      time.Duration `conf:"chaos-watch" help:"How often to check the chaos config file for changes and reload it (default: 0, never)"`
+	ChaosHeader     bool          `conf:"chaos-header" help:"Let clients pick their own chaos with the X-Chaos header, e.g. 'X-Chaos: statusCode=503' (default: false)"`
+	ChaosAdmin      bool          `conf:"chaos-admin" help:"Serve the unauthenticated admin API to inspect and change the chaos at /internal/chaos (default: false)"`
+	ChaosSeed       int64         `conf:"chaos-seed" help:"Seed for chaos' random decisions, to reproduce a run (default: 0, seeded from the clock)"`
+	Limits          string        `conf:"limits" help:"file to load request size limits per route and writeKey from (default: 32KB per message, 500KB per batch)"`
 	ShutdownTimeout time.Duration `conf:"shutdown-timeout" help:"Time limit for shutting down tracking-api (default: 5s)"`
 }
 
@@ -37,6 +43,7 @@ func main() {
 	config := config{
 		Bind:            ":8080",
 		Out:             "/dev/null",
+		Dropped:         "/dev/null",
 		ShutdownTimeout: 5 * time.Second,
 	}
 	conf.Load(&config)
@@ -61,13 +68,32 @@ func main() {
 			events.Log("readying chaos config '${chaosConfig}s': %{error}s", config.ChaosConfig, err)
 		}
 	}
-	var chaosRoot chaos.WeightedChaos
-	err = yaml.Unmarshal(chaosConfigBytes, &chaosRoot)
+	if config.ChaosSeed == 0 {
+		config.ChaosSeed = time.Now().UnixNano()
+	}
+	chaos.Seed(config.ChaosSeed)
+	events.Log("chaos seed: %{chaosSeed}d", config.ChaosSeed)
+
+	chaosRoot, err := chaos.ParseConfig(chaosConfigBytes)
 	if err != nil {
 		events.Log("unmarshaling chaoses failed: %{error}s", err)
 		os.Exit(1)
 	}
 
+	if config.Limits != "" {
+		limitsBytes, err := ioutil.ReadFile(config.Limits)
+		if err != nil {
+			events.Log("reading limits %{limits}s failed: %{error}s", config.Limits, err)
+			os.Exit(1)
+		}
+		limits, err := message.ParseLimits(limitsBytes)
+		if err != nil {
+			events.Log("unmarshaling limits %{limits}s failed: %{error}s", config.Limits, err)
+			os.Exit(1)
+		}
+		message.SetLimits(limits)
+	}
+
 	out, err := os.Create(config.Out)
 	if err != nil {
 		events.Log("opening out %{out}s failed: %{error}s", config.Out, err)
@@ -75,11 +101,27 @@ func main() {
 	}
 	defer out.Close()
 
+	dropped, err := os.Create(config.Dropped)
+	if err != nil {
+		events.Log("opening dropped %{dropped}s failed: %{error}s", config.Dropped, err)
+		os.Exit(1)
+	}
+	defer dropped.Close()
+
 	events.Log("starting %s, version: %s", os.Args[0], Version)
 	events.Debug("chaosRoot: %#v", chaosRoot)
 
+	apiServer := api.New(out, dropped, chaosRoot)
+	apiServer.AllowChaosHeader(config.ChaosHeader)
+	apiServer.AllowAdmin(config.ChaosAdmin)
+	// SIGHUP reloads the chaos config; it's always handled so that it never
+	// kills the server, even when there's no file to reload.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go watchChaos(config.ChaosConfig, config.ChaosWatch, hup, apiServer.Chaos())
+
 	var handler http.Handler
-	handler = api.New(out, chaosRoot)
+	handler = apiServer
 
 	if config.Debug {
 		handler = httpevents.NewHandler(handler)
@@ -92,7 +134,7 @@ func main() {
 	}
 	defer lstn.Close()
 
-	sigsend := make(chan os.Signal)
+	sigsend := make(chan os.Signal, 1)
 	sigrecv := events.Signal(sigsend)
 	signal.Notify(sigsend, syscall.SIGINT, syscall.SIGTERM)
 
@@ -112,6 +154,9 @@ func main() {
 		// the termination of the program.
 		signal.Stop(sigsend)
 
+		// Don't let requests sitting in injected latency hold up the shutdown.
+		chaos.Shutdown()
+
 		ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
 		defer cancel()
 		server.Shutdown(ctx)